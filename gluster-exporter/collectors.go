@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gluster/gluster-prometheus/pkg/conf"
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+	"github.com/gluster/gluster-prometheus/pkg/logging"
+	"github.com/gluster/gluster-prometheus/pkg/lvm"
+	"github.com/gluster/gluster-prometheus/pkg/metrics"
+
+	"github.com/prometheus/exporter-toolkit/web"
+	log "github.com/sirupsen/logrus"
+)
+
+// collector represents a collector enabled in the configuration
+type collector struct {
+	metric   metrics.GlusterMetric
+	interval time.Duration
+}
+
+// exporter runs the collectors enabled in the configuration and
+// restarts them with the new configuration on reload
+type exporter struct {
+	confFile  string
+	overrides *conf.Overrides
+	// lock serializes the reloads
+	lock  sync.Mutex
+	conf  *conf.Config
+	stopC chan struct{}
+	// cancel kills the gluster CLI commands of the collectors
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func isLogFileStd(logFile string) bool {
+	return strings.ToLower(logFile) == "stderr" || logFile == "-" || strings.ToLower(logFile) == "stdout"
+}
+
+// loadConfig loads the configuration file, applies the
+// environment and command line overrides and validates it
+func loadConfig(confFile string, overrides *conf.Overrides) (*conf.Config, error) {
+	exporterConf, err := conf.LoadConfig(confFile, overrides)
+	if err != nil {
+		return nil, err
+	}
+	// Set the Gluster Configurations used in glusterutils
+	if exporterConf.GlusterdWorkdir == "" {
+		exporterConf.GlusterdWorkdir =
+			getDefaultGlusterdDir(exporterConf.GlusterMgmt)
+	}
+	if err := exporterConf.Validate(collectorNames(), glusterutils.CacheableFuncs()); err != nil {
+		return nil, err
+	}
+	if err := web.Validate(exporterConf.WebConfigFile); err != nil {
+		return nil, fmt.Errorf("invalid web-config-file %q: %v", exporterConf.WebConfigFile, err)
+	}
+	if len(enabledCollectors(exporterConf)) == 0 {
+		return nil, errors.New("no collectors enabled")
+	}
+	return exporterConf, nil
+}
+
+// collectorNames returns the names of all the available collectors
+func collectorNames() []string {
+	names := make([]string, 0, len(metrics.GlusterMetrics))
+	for _, m := range metrics.GlusterMetrics {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+// enabledCollectors returns the collectors to be run for the configuration
+func enabledCollectors(exporterConf *conf.Config) []collector {
+	var collectors []collector
+	for _, m := range metrics.GlusterMetrics {
+		interval := defaultInterval
+		if c, ok := exporterConf.CollectorsConf[m.Name]; ok {
+			if c.Disabled {
+				continue
+			}
+			if c.SyncInterval > 0 {
+				interval = time.Duration(c.SyncInterval) * time.Second
+			}
+		} else if m.Optional {
+			// optional collectors are run only if configured
+			continue
+		}
+		collectors = append(collectors, collector{metric: m, interval: interval})
+	}
+	return collectors
+}
+
+func initLogging(exporterConf *conf.Config) error {
+	if !isLogFileStd(exporterConf.LogFile) {
+		// Create Log dir
+		if err := os.MkdirAll(exporterConf.LogDir, 0750); err != nil {
+			return err
+		}
+	}
+	return logging.Init(exporterConf.LogDir, exporterConf.LogFile, exporterConf.LogLevel)
+}
+
+// lvmCollectors are the collectors using the LVM report
+var lvmCollectors = []string{"gluster_brick", "gluster_peer_counts"}
+
+// lvmCacheTTL returns the duration for which the LVM report is shared by
+// the collectors. The collectors run on independent tickers, so unless
+// configured the report is cached for slightly less than the shortest
+// sync interval of the collectors using it, to scan once in each cycle.
+func lvmCacheTTL(exporterConf *conf.Config) time.Duration {
+	if exporterConf.LVMCacheTTL > 0 {
+		return time.Duration(exporterConf.LVMCacheTTL) * time.Second
+	}
+	var interval time.Duration
+	for _, c := range enabledCollectors(exporterConf) {
+		for _, name := range lvmCollectors {
+			if c.metric.Name == name && (interval == 0 || c.interval < interval) {
+				interval = c.interval
+			}
+		}
+	}
+	if interval == 0 {
+		return lvm.DefaultCacheTTL
+	}
+	return interval * 9 / 10
+}
+
+// applyConfig sets the configurations used by the collectors, the
+// options not set in the configuration are reset to the defaults
+func applyConfig(exporterConf *conf.Config) {
+	// exporter's config will have proper Cluster ID set
+	metrics.ClusterID = exporterConf.GlusterClusterID
+	metrics.ClientIPLabels = exporterConf.VolumeClientsIPLabels
+	metrics.ClientIPLabelsLimit = metrics.DefaultClientIPLabelsLimit
+	if exporterConf.VolumeClientsIPLabelsLimit > 0 {
+		metrics.ClientIPLabelsLimit = exporterConf.VolumeClientsIPLabelsLimit
+	}
+	metrics.GlusterProcesses = metrics.DefaultGlusterProcesses
+	if len(exporterConf.PSProcessNames) > 0 {
+		metrics.GlusterProcesses = exporterConf.PSProcessNames
+	}
+	lvm.CacheTTL = lvmCacheTTL(exporterConf)
+	metrics.BrickInternalsVolumes = exporterConf.BrickInternalsVolumes
+	metrics.BrickInternalsMaxEntries = metrics.DefaultBrickInternalsMaxEntries
+	if exporterConf.BrickInternalsMaxEntries > 0 {
+		metrics.BrickInternalsMaxEntries = exporterConf.BrickInternalsMaxEntries
+	}
+}
+
+// start starts the collectors enabled in the configuration
+func (e *exporter) start(exporterConf *conf.Config) {
+	applyConfig(exporterConf)
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(context.Background())
+	gluster := glusterutils.MakeGlusterContext(ctx, exporterConf)
+
+	e.conf = exporterConf
+	e.stopC = make(chan struct{})
+	for _, c := range enabledCollectors(exporterConf) {
+		e.wg.Add(1)
+		go func(m metrics.GlusterMetric, gi glusterutils.GInterface, itvl time.Duration, stopC chan struct{}) {
+			defer e.wg.Done()
+			for {
+				if err := m.FN(gi); err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name": m.Name,
+					}).Debug("failed to export metric")
+				}
+				select {
+				case <-stopC:
+					return
+				case <-time.After(itvl):
+				}
+			}
+		}(c.metric, gluster, c.interval, e.stopC)
+	}
+}
+
+// stop stops the collectors and waits for the running collections to
+// complete, the gluster CLI commands of the collections are killed
+func (e *exporter) stop() {
+	close(e.stopC)
+	e.cancel()
+	e.wg.Wait()
+}
+
+// resetDisabledCollectors removes the metrics of the collectors
+// disabled by the new configuration, the collectors remove only
+// their own stale metrics and so won't once they stop running
+func resetDisabledCollectors(oldConf, newConf *conf.Config) {
+	enabled := make(map[string]bool)
+	for _, c := range enabledCollectors(newConf) {
+		enabled[c.metric.Name] = true
+	}
+	for _, c := range enabledCollectors(oldConf) {
+		if !enabled[c.metric.Name] && c.metric.Reset != nil {
+			c.metric.Reset()
+			log.WithField("name", c.metric.Name).Info("Collector disabled")
+		}
+	}
+}
+
+// reload reloads the configuration file and restarts the collectors
+// with the new configuration. Running configuration is retained if
+// the new configuration is invalid.
+func (e *exporter) reload() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	newConf, err := loadConfig(e.confFile, e.overrides)
+	if err != nil {
+		metrics.SetConfigReloaded(false)
+		return err
+	}
+	if newConf.ListenAddress != e.conf.ListenAddress || newConf.Port != e.conf.Port ||
+		newConf.MetricsPath != e.conf.MetricsPath {
+		log.Warn("Changes to listen-address, port and metrics-path are applied only after restart")
+	}
+
+	e.stop()
+	resetDisabledCollectors(e.conf, newConf)
+	if err := initLogging(newConf); err != nil {
+		// continue with the new configuration, logging
+		// falls back to stderr on failure
+		log.WithError(err).Error("Failed to initialize logging")
+	}
+	e.start(newConf)
+	metrics.SetConfigReloaded(true)
+	log.WithField("config", e.confFile).Info("Configuration reloaded")
+	return nil
+}
+
+// currentConf returns the running configuration
+func (e *exporter) currentConf() *conf.Config {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.conf
+}
+
+// handleSignals reloads the configuration on SIGHUP
+func (e *exporter) handleSignals() {
+	hupC := make(chan os.Signal, 1)
+	signal.Notify(hupC, syscall.SIGHUP)
+	for range hupC {
+		if err := e.reload(); err != nil {
+			log.WithError(err).Error("Failed to reload the configuration")
+		}
+	}
+}
+
+// reloadHandler reloads the configuration on a POST or PUT request to
+// /-/reload, enabled only if enable-reload-endpoint is set
+func (e *exporter) reloadHandler(w http.ResponseWriter, r *http.Request) {
+	if !e.currentConf().EnableReloadEndpoint {
+		http.Error(w, "Reload endpoint is disabled", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		w.Header().Set("Allow", "POST, PUT")
+		http.Error(w, "Only POST or PUT requests allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := e.reload(); err != nil {
+		log.WithError(err).Error("Failed to reload the configuration")
+		http.Error(w, "Failed to reload the configuration: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("OK\n")) // #nosec
+}
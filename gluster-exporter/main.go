@@ -3,21 +3,22 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"runtime"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/gluster/gluster-prometheus/pkg/conf"
 	"github.com/gluster/gluster-prometheus/pkg/doc"
-	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
 	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
 	"github.com/gluster/gluster-prometheus/pkg/logging"
 	"github.com/gluster/gluster-prometheus/pkg/metrics"
 
 	"github.com/Showmax/go-fqdn"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/exporter-toolkit/web"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -34,6 +35,7 @@ var (
 	showVersion     = flag.Bool("version", false, "Show the version information")
 	docgen          = flag.Bool("docgen", false, "Generate exported metrics documentation in Asciidoc format")
 	config          = flag.String("config", defaultConfFile, "Config file path")
+	checkConfig     = flag.Bool("check-config", false, "Validate the config file and exit")
 	defaultInterval = time.Minute
 )
 
@@ -50,11 +52,31 @@ func getDefaultGlusterdDir(mgmt string) string {
 	return defaultGlusterd1Workdir
 }
 
+// runCheckConfig validates the config file, prints the problems
+// found and returns the exit code
+func runCheckConfig(confFile string, overrides *conf.Overrides) int {
+	if _, err := loadConfig(confFile, overrides); err != nil {
+		fmt.Fprintf(os.Stderr, "Config file %s is invalid\n", confFile)
+		if verr, ok := err.(*conf.ValidationError); ok {
+			for _, problem := range verr.Problems {
+				fmt.Fprintf(os.Stderr, "  - %s\n", problem)
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "  - %s\n", err)
+		}
+		return 1
+	}
+	fmt.Printf("Config file %s is valid\n", confFile)
+	return 0
+}
+
 func main() {
 	// Init logger with stderr, will be reinitialized later
 	if err := logging.Init("", "-", "info"); err != nil {
 		log.Fatal("Init logging failed for stderr")
 	}
+	// flags overriding the config file options
+	overrides := conf.NewOverrides(flag.CommandLine, collectorNames())
 	flag.Parse()
 
 	if *docgen {
@@ -67,73 +89,39 @@ func main() {
 		return
 	}
 
+	if *checkConfig {
+		os.Exit(runCheckConfig(*config, overrides))
+	}
+
 	f, err := fqdn.FqdnHostname()
 	if err != nil {
 		log.WithError(err).Fatal("Failed to guess FQDN")
 	}
 	metrics.InstanceFQDN = f
 
-	var gluster glusterutils.GInterface
-	exporterConf, err := conf.LoadConfig(*config)
+	exporterConf, err := loadConfig(*config, overrides)
 	if err != nil {
 		log.WithError(err).Fatal("Loading global config failed")
 	}
 
-	if strings.ToLower(exporterConf.LogFile) != "stderr" && exporterConf.LogFile != "-" && strings.ToLower(exporterConf.LogFile) != "stdout" {
-		// Create Log dir
-		err = os.MkdirAll(exporterConf.LogDir, 0750)
-		if err != nil {
-			log.WithError(err).WithField("logdir", exporterConf.LogDir).
-				Fatal("Failed to create log directory")
-		}
-	}
-
-	if err := logging.Init(exporterConf.LogDir, exporterConf.LogFile, exporterConf.LogLevel); err != nil {
+	if err := initLogging(exporterConf); err != nil {
 		log.WithError(err).Fatal("Failed to initialize logging")
 	}
 
-	// Set the Gluster Configurations used in glusterutils
-	if exporterConf.GlusterdWorkdir == "" {
-		exporterConf.GlusterdWorkdir =
-			getDefaultGlusterdDir(exporterConf.GlusterMgmt)
-	}
-	// exporter's config will have proper Cluster ID set
-	metrics.ClusterID = exporterConf.GlusterClusterID
-
-	gluster = glusterutils.MakeGluster(exporterConf)
-	registered := 0
-	for _, m := range metrics.GlusterMetrics {
-		interval := defaultInterval
-		if c, ok := exporterConf.CollectorsConf[m.Name]; ok {
-			if c.Disabled {
-				continue
-			}
-			if c.SyncInterval > 0 {
-				interval = time.Duration(c.SyncInterval) * time.Second
-			}
-		}
-
-		go func(m metrics.GlusterMetric, gi glusterutils.GInterface, itvl time.Duration) {
-			for {
-				if err := m.FN(gi); err != nil {
-					log.WithError(err).WithFields(log.Fields{
-						"name": m.Name,
-					}).Debug("failed to export metric")
-				}
-				time.Sleep(itvl)
-			}
-		}(m, gluster, interval)
-		registered++
-	}
-
-	if registered == 0 {
-		_, _ = fmt.Fprintf(os.Stderr, "No Metrics registered, Exiting..\n")
-		os.Exit(1)
-	}
+	exp := &exporter{confFile: *config, overrides: overrides}
+	exp.start(exporterConf)
+	metrics.SetConfigLoaded()
+	go exp.handleSignals()
 
 	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("OK\n")) })
 	http.Handle(exporterConf.MetricsPath, promhttp.Handler())
-	if err := http.ListenAndServe(fmt.Sprintf(":%d", exporterConf.Port), nil); err != nil {
+	http.HandleFunc("/-/reload", exp.reloadHandler)
+	// TLS and basic authentication are enabled using the web
+	// configuration file, served over plain HTTP if not configured
+	server := &http.Server{
+		Addr: net.JoinHostPort(exporterConf.ListenAddress, strconv.Itoa(exporterConf.Port)),
+	}
+	if err := web.ListenAndServe(server, exporterConf.WebConfigFile, logging.NewKitLogger()); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Failed to run exporter\nError: %s", err)
 		log.WithError(err).Fatal("Failed to run exporter")
 	}
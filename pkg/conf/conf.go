@@ -2,12 +2,15 @@ package conf
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/BurntSushi/toml"
 	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // GConfig represents Glusterd1/Glusterd2 configurations
@@ -19,22 +22,56 @@ type GConfig struct {
 	GlusterGlusterdSock string `toml:"gd1-glusterd-sock"`
 	GlusterdWorkdir     string `toml:"glusterd-dir"`
 	GlusterClusterID    string `toml:"gluster-cluster-id"`
-	Glusterd2User       string
-	Glusterd2Secret     string
-	Glusterd2Cacert     string
-	Glusterd2Insecure   bool
-	Timeout             int64
+	Glusterd2User       string `toml:"gd2-user"`
+	Glusterd2Secret     string `toml:"gd2-secret"`
+	Glusterd2Cacert     string `toml:"gd2-cacert"`
+	Glusterd2Insecure   bool   `toml:"gd2-insecure"`
+	Timeout             int64  `toml:"timeout"`
+	// glusterd2 REST client connection and retry settings
+	Glusterd2ConnectTimeout int64 `toml:"gd2-connect-timeout-in-sec"`
+	Glusterd2Retries        int   `toml:"gd2-retries"`
+	Glusterd2RetryBackoff   int64 `toml:"gd2-retry-backoff-in-ms"`
+	// gluster CLI concurrency and retry settings
+	GlusterCLIConcurrency  int   `toml:"gluster-cli-concurrency"`
+	GlusterCLIRetries      int   `toml:"gluster-cli-retries"`
+	GlusterCLIRetryBackoff int64 `toml:"gluster-cli-retry-backoff-in-ms"`
+}
+
+// Glusterd2Endpoints returns the glusterd2 endpoints, multiple
+// endpoints are separated by commas or spaces in the order of preference
+func (gconf *GConfig) Glusterd2Endpoints() []string {
+	return strings.Fields(strings.Replace(gconf.Glusterd2Endpoint, ",", " ", -1))
 }
 
 // Globals maintains the global system configurations
 type Globals struct {
+	ListenAddress     string   `toml:"listen-address"`
 	Port              int      `toml:"port"`
+	WebConfigFile     string   `toml:"web-config-file"`
 	MetricsPath       string   `toml:"metrics-path"`
 	LogDir            string   `toml:"log-dir"`
 	LogFile           string   `toml:"log-file"`
 	LogLevel          string   `toml:"log-level"`
 	CacheTTL          uint64   `toml:"cache-ttl-in-sec"`
 	CacheEnabledFuncs []string `toml:"cache-enabled-funcs"`
+	// negative caching and serving stale values on errors
+	CacheNegativeTTL  uint64 `toml:"cache-negative-ttl-in-sec"`
+	CacheServeStale   bool   `toml:"cache-serve-stale"`
+	CacheMaxStaleness uint64 `toml:"cache-max-staleness-in-sec"`
+	// time_to_live of the cached functions, overriding cache-ttl-in-sec
+	CacheFuncTTL map[string]uint64 `toml:"cache-func-ttl-in-sec"`
+	// per client IP labels of gluster_volume_clients collector
+	VolumeClientsIPLabels      bool `toml:"volume-clients-ip-labels"`
+	VolumeClientsIPLabelsLimit int  `toml:"volume-clients-ip-labels-limit"`
+	// process names monitored by gluster_ps collector
+	PSProcessNames []string `toml:"ps-process-names"`
+	// duration for which the LVM report is shared by the collectors
+	LVMCacheTTL uint64 `toml:"lvm-cache-ttl-in-sec"`
+	// bricks scanned by gluster_brick_internals collector
+	BrickInternalsVolumes    []string `toml:"brick-internals-volumes"`
+	BrickInternalsMaxEntries int      `toml:"brick-internals-max-entries"`
+	// enables reloading the configuration using /-/reload endpoint
+	EnableReloadEndpoint bool `toml:"enable-reload-endpoint"`
 	*GConfig
 }
 
@@ -50,6 +87,12 @@ type Collectors struct {
 type Config struct {
 	*Globals       `toml:"globals"`
 	CollectorsConf map[string]Collectors `toml:"collectors"`
+	// keys in the configuration file which are not
+	// decoded into any of the configuration fields
+	undecoded []string
+	// GLUSTER_EXPORTER_* environment variables
+	// not matching any of the options
+	unknownEnv []string
 }
 
 // GConfig method helps 'Config' objects to implement 'GConfigInterface'
@@ -57,32 +100,126 @@ func (conf *Config) GConfig() *GConfig {
 	return conf.Globals.GConfig
 }
 
-// LoadConfig loads the configuration file
-func LoadConfig(confFilePath string) (conf *Config, err error) {
-	conf = &Config{}
-	if _, err = toml.DecodeFile(filepath.Clean(confFilePath), conf); err != nil {
+const (
+	// DefaultGlusterd2Retries is the default no of
+	// times a failed glusterd2 request is retried
+	DefaultGlusterd2Retries = 2
+	// DefaultGlusterCLIConcurrency is the default no of
+	// gluster CLI commands run concurrently
+	DefaultGlusterCLIConcurrency = 2
+	// DefaultGlusterCLIRetries is the default no of times a
+	// gluster CLI command failed as glusterd is busy is retried
+	DefaultGlusterCLIRetries = 3
+)
+
+// newConfig returns the configuration with the defaults of the options
+// for which 0 is a valid value, the configuration file is decoded into
+// it so that the defaults are retained only if the options are not set
+func newConfig() *Config {
+	return &Config{Globals: &Globals{GConfig: &GConfig{
+		Glusterd2Retries:      DefaultGlusterd2Retries,
+		GlusterCLIConcurrency: DefaultGlusterCLIConcurrency,
+		GlusterCLIRetries:     DefaultGlusterCLIRetries,
+	}}}
+}
+
+// legacyKeys maps the keys of the glusterd2 options named after the
+// 'GConfig' fields, used before the options had 'toml' tags
+var legacyKeys = map[string]string{
+	"glusterd2user":     "gd2-user",
+	"glusterd2secret":   "gd2-secret",
+	"glusterd2cacert":   "gd2-cacert",
+	"glusterd2insecure": "gd2-insecure",
+}
+
+// decodeLegacyKeys decodes the options set using the legacy keys, which
+// are otherwise reported as unknown keys. The current keys take the
+// precedence if both are set.
+func (conf *Config) decodeLegacyKeys(confFilePath string) error {
+	var legacy struct {
+		Globals map[string]toml.Primitive `toml:"globals"`
+	}
+	md, err := toml.DecodeFile(filepath.Clean(confFilePath), &legacy)
+	if err != nil {
+		return err
+	}
+	var undecoded []string
+	for _, key := range conf.undecoded {
+		name := strings.TrimPrefix(key, "globals.")
+		newKey, ok := legacyKeys[strings.ToLower(name)]
+		if !ok || name == key {
+			undecoded = append(undecoded, key)
+			continue
+		}
+		log.Warnf("Configuration key %q is deprecated, use %q instead", name, newKey)
+		if conf.isDefined(md, newKey) {
+			continue
+		}
+		var dst interface{}
+		switch newKey {
+		case "gd2-user":
+			dst = &conf.Glusterd2User
+		case "gd2-secret":
+			dst = &conf.Glusterd2Secret
+		case "gd2-cacert":
+			dst = &conf.Glusterd2Cacert
+		case "gd2-insecure":
+			dst = &conf.Glusterd2Insecure
+		}
+		if err := md.PrimitiveDecode(legacy.Globals[name], dst); err != nil {
+			return fmt.Errorf("invalid value of %q: %v", name, err)
+		}
+	}
+	conf.undecoded = undecoded
+	return nil
+}
+
+// isDefined returns true if the globals option is set in the file
+func (conf *Config) isDefined(md toml.MetaData, key string) bool {
+	for _, k := range md.Keys() {
+		if len(k) == 2 && k[0] == "globals" && strings.EqualFold(k[1], key) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadConfig loads the configuration file, and applies the
+// overrides on it. 'overrides' can be nil.
+func LoadConfig(confFilePath string, overrides *Overrides) (conf *Config, err error) {
+	conf = newConfig()
+	md, err := toml.DecodeFile(filepath.Clean(confFilePath), conf)
+	if err != nil {
 		conf = nil
 		return
 	}
-	// by default, use glusterd (that is; GD1)
-	if conf.GlusterMgmt == "" {
-		conf.GlusterMgmt = glusterconsts.MgmtGlusterd
+	for _, key := range md.Undecoded() {
+		conf.undecoded = append(conf.undecoded, key.String())
+	}
+	if err = conf.decodeLegacyKeys(confFilePath); err != nil {
+		conf = nil
+		return
 	}
 	// If GD2_ENDPOINTS env variable is set, use that info
 	// for making REST API calls
 	if endpoint := os.Getenv(glusterconsts.EnvGD2Endpoints); endpoint != "" {
 		conf.Glusterd2Endpoint = endpoint
 	}
-	// if there are multiple endpoints, get the first one
-	if endpoint := conf.Glusterd2Endpoint; endpoint != "" {
-		endpoint = strings.Replace(endpoint, ",", " ", -1)
-		endpoint = strings.Fields(endpoint)[0]
-		conf.Glusterd2Endpoint = endpoint
-	}
 	// if GLUSTER_CLUSTER_ID env variable is set, it gets the precedence
+	// over the configuration file
 	if gClusterID := os.Getenv(glusterconsts.EnvGlusterClusterID); gClusterID != "" {
 		conf.GlusterClusterID = gClusterID
 	}
+	if overrides != nil {
+		if err = overrides.apply(conf); err != nil {
+			conf = nil
+			return
+		}
+	}
+	// by default, use glusterd (that is; GD1)
+	if conf.GlusterMgmt == "" {
+		conf.GlusterMgmt = glusterconsts.MgmtGlusterd
+	}
 	// gluster cluster ID is still empty, put the default
 	if conf.GlusterClusterID == "" {
 		conf.GlusterClusterID = glusterconsts.DefaultGlusterClusterID
@@ -0,0 +1,307 @@
+package conf
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is the prefix of the environment variables
+// overriding the configuration file options
+const EnvPrefix = "GLUSTER_EXPORTER_"
+
+// option is a configuration option which can be overridden
+type option struct {
+	// key is the name of the option in the configuration file,
+	// and the name of the command line flag
+	key string
+	env string
+	// index of the field in 'Globals' or 'Collectors'
+	index []int
+	kind  reflect.Kind
+	// envOnly options can't be overridden using the command line
+	// flags, as the command line is visible to all the users
+	envOnly bool
+}
+
+// envOnlyOptions are the options holding secrets
+var envOnlyOptions = map[string]bool{
+	"gd2-secret": true,
+}
+
+// Overrides holds the configuration options overridden using the
+// GLUSTER_EXPORTER_* environment variables and the command line flags.
+//
+// Options are applied in the below order, each one overriding the
+// previous ones,
+//  1. configuration file
+//  2. GD2_ENDPOINTS and GLUSTER_CLUSTER_ID environment variables
+//  3. GLUSTER_EXPORTER_* environment variables
+//  4. command line flags
+type Overrides struct {
+	globals []option
+	// options of each of the collectors
+	collectors map[string][]option
+	// values of the command line flags set, keyed by flag name
+	flagValues map[string]string
+}
+
+// overrideValue implements 'flag.Value' and records
+// the value of the flag set in the command line
+type overrideValue struct {
+	name   string
+	isBool bool
+	values map[string]string
+}
+
+func (v *overrideValue) String() string {
+	if v == nil || v.values == nil {
+		return ""
+	}
+	return v.values[v.name]
+}
+
+func (v *overrideValue) Set(s string) error {
+	v.values[v.name] = s
+	return nil
+}
+
+func (v *overrideValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// optionKey returns the 'toml' key of the field, the field name is
+// the key of the fields without a 'toml' tag as in the toml decoder
+func optionKey(field reflect.StructField) string {
+	if tag := strings.Split(field.Tag.Get("toml"), ",")[0]; tag != "" {
+		return tag
+	}
+	return field.Name
+}
+
+func envName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
+
+// structOptions lists the overridable options of the struct type,
+// fields of the embedded structs are listed as its own
+func structOptions(t reflect.Type, prefix string, index []int) []option {
+	var options []option
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fIndex := append(append([]int{}, index...), i)
+		fType := field.Type
+		if fType.Kind() == reflect.Ptr {
+			fType = fType.Elem()
+		}
+		if field.Anonymous && fType.Kind() == reflect.Struct {
+			options = append(options, structOptions(fType, prefix, fIndex)...)
+			continue
+		}
+		if field.PkgPath != "" {
+			// unexported field
+			continue
+		}
+		switch fType.Kind() {
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Uint64:
+		case reflect.Slice:
+			if fType.Elem().Kind() != reflect.String {
+				continue
+			}
+		case reflect.Map:
+			if fType.Key().Kind() != reflect.String || fType.Elem().Kind() != reflect.Uint64 {
+				continue
+			}
+		default:
+			continue
+		}
+		key := prefix + optionKey(field)
+		options = append(options, option{
+			key:     key,
+			env:     envName(key),
+			index:   fIndex,
+			kind:    fType.Kind(),
+			envOnly: envOnlyOptions[key],
+		})
+	}
+	return options
+}
+
+// NewOverrides creates the overrides for all the options of the
+// configuration file, and registers the command line flags for them
+// in the given flag set. Options of the collectors are registered as
+// 'collector.<name>.<option>' flags for each of the given collectors.
+func NewOverrides(fs *flag.FlagSet, collectors []string) *Overrides {
+	o := &Overrides{
+		globals:    structOptions(reflect.TypeOf(Globals{}), "", nil),
+		collectors: make(map[string][]option),
+		flagValues: make(map[string]string),
+	}
+	for _, name := range collectors {
+		prefix := "collector." + name + "."
+		for _, opt := range structOptions(reflect.TypeOf(Collectors{}), prefix, nil) {
+			// name of the collector is its key
+			if opt.key == prefix+"name" {
+				continue
+			}
+			o.collectors[name] = append(o.collectors[name], opt)
+		}
+	}
+
+	register := func(opt option, usage string) {
+		if opt.envOnly {
+			return
+		}
+		fs.Var(&overrideValue{name: opt.key, isBool: opt.kind == reflect.Bool, values: o.flagValues},
+			opt.key, fmt.Sprintf("%s (env %s)", usage, opt.env))
+	}
+	for _, opt := range o.globals {
+		register(opt, fmt.Sprintf("Overrides %q option of the config file", opt.key))
+	}
+	for _, name := range collectors {
+		for _, opt := range o.collectors[name] {
+			register(opt, fmt.Sprintf("Overrides %q option of %s collector", strings.TrimPrefix(opt.key, "collector."+name+"."), name))
+		}
+	}
+	return o
+}
+
+// value returns the overridden value of the option, and
+// the source it is overridden by
+func (o *Overrides) value(opt option) (string, string, bool) {
+	if v, ok := o.flagValues[opt.key]; ok {
+		return v, "flag --" + opt.key, true
+	}
+	if v, ok := os.LookupEnv(opt.env); ok {
+		return v, "environment variable " + opt.env, true
+	}
+	return "", "", false
+}
+
+func setField(field reflect.Value, kind reflect.Kind, value string) error {
+	switch kind {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Slice:
+		// comma separated list, empty value clears the list
+		list := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+	case reflect.Map:
+		// comma separated list of key=value, empty value clears the map
+		m := make(map[string]uint64)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("%q is not in key=value format", item)
+			}
+			u, err := strconv.ParseUint(strings.TrimSpace(kv[1]), 10, 64)
+			if err != nil {
+				return err
+			}
+			m[strings.TrimSpace(kv[0])] = u
+		}
+		field.Set(reflect.ValueOf(m))
+	}
+	return nil
+}
+
+// fieldByIndex returns the field with the given index,
+// allocating the embedded struct pointers on the way
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// apply sets the overridden options in the configuration
+func (o *Overrides) apply(conf *Config) error {
+	if conf.Globals == nil {
+		conf.Globals = &Globals{}
+	}
+	globals := reflect.ValueOf(conf.Globals).Elem()
+	for _, opt := range o.globals {
+		if v, src, ok := o.value(opt); ok {
+			if err := setField(fieldByIndex(globals, opt.index), opt.kind, v); err != nil {
+				return fmt.Errorf("invalid value %q of %s: %v", v, src, err)
+			}
+		}
+	}
+
+	for name, options := range o.collectors {
+		for _, opt := range options {
+			v, src, ok := o.value(opt)
+			if !ok {
+				continue
+			}
+			if conf.CollectorsConf == nil {
+				conf.CollectorsConf = make(map[string]Collectors)
+			}
+			// collectors not in the configuration file are added,
+			// this enables an optional collector as well
+			c, ok := conf.CollectorsConf[name]
+			if !ok {
+				c.Name = name
+			}
+			cValue := reflect.ValueOf(&c).Elem()
+			if err := setField(fieldByIndex(cValue, opt.index), opt.kind, v); err != nil {
+				return fmt.Errorf("invalid value %q of %s: %v", v, src, err)
+			}
+			conf.CollectorsConf[name] = c
+		}
+	}
+
+	// unknown GLUSTER_EXPORTER_* environment variables
+	// are reported while validating the configuration
+	known := make(map[string]struct{})
+	for _, opt := range o.globals {
+		known[opt.env] = struct{}{}
+	}
+	for _, options := range o.collectors {
+		for _, opt := range options {
+			known[opt.env] = struct{}{}
+		}
+	}
+	for _, env := range os.Environ() {
+		name := strings.SplitN(env, "=", 2)[0]
+		if _, ok := known[name]; !ok && strings.HasPrefix(name, EnvPrefix) {
+			conf.unknownEnv = append(conf.unknownEnv, name)
+		}
+	}
+	return nil
+}
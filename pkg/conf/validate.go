@@ -0,0 +1,159 @@
+package conf
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// reservedPaths are the HTTP paths served by the exporter
+// other than the metrics path
+var reservedPaths = []string{"/healthz", "/-/reload"}
+
+// ValidationError lists all the problems found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *ValidationError) addf(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the configuration for unknown keys and invalid
+// values. 'collectors' and 'cacheFuncs' are the names of the available
+// collectors and the functions for which caching can be enabled.
+// Returns a '*ValidationError' listing all the problems found.
+func (conf *Config) Validate(collectors []string, cacheFuncs []string) error {
+	verr := &ValidationError{}
+	for _, key := range conf.undecoded {
+		verr.addf("unknown configuration key %q", key)
+	}
+	for _, env := range conf.unknownEnv {
+		verr.addf("unknown environment variable %q", env)
+	}
+	if conf.Globals == nil {
+		verr.addf("missing [globals] section")
+		return verr
+	}
+	conf.validateGlobals(verr, cacheFuncs)
+
+	names := make([]string, 0, len(conf.CollectorsConf))
+	for name := range conf.CollectorsConf {
+		names = append(names, name)
+	}
+	// report the problems in the same order always
+	sort.Strings(names)
+	for _, name := range names {
+		c := conf.CollectorsConf[name]
+		if !contains(collectors, name) {
+			verr.addf("unknown collector %q in [collectors.%s], available collectors are: %s",
+				name, name, strings.Join(collectors, ", "))
+			continue
+		}
+		if c.Name != "" && c.Name != name {
+			verr.addf("name %q of [collectors.%s] does not match the collector", c.Name, name)
+		}
+	}
+
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func (conf *Config) validateGlobals(verr *ValidationError, cacheFuncs []string) {
+	if conf.Globals.GConfig != nil {
+		switch conf.GlusterMgmt {
+		case glusterconsts.MgmtGlusterd:
+		case glusterconsts.MgmtGlusterd2:
+			for _, endpoint := range conf.Glusterd2Endpoints() {
+				if u, err := url.Parse(endpoint); err != nil ||
+					(u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					verr.addf("gd2-rest-endpoint %q is not a valid http(s) URL", endpoint)
+				}
+			}
+		default:
+			verr.addf("gluster-mgmt %q is not one of %q, %q", conf.GlusterMgmt,
+				glusterconsts.MgmtGlusterd, glusterconsts.MgmtGlusterd2)
+		}
+		if conf.GlusterCLIConcurrency < 0 {
+			verr.addf("gluster-cli-concurrency %d must not be negative", conf.GlusterCLIConcurrency)
+		}
+		if conf.GlusterCLIRetries < 0 {
+			verr.addf("gluster-cli-retries %d must not be negative", conf.GlusterCLIRetries)
+		}
+		if conf.GlusterCLIRetryBackoff < 0 {
+			verr.addf("gluster-cli-retry-backoff-in-ms %d must not be negative", conf.GlusterCLIRetryBackoff)
+		}
+		if conf.Glusterd2ConnectTimeout < 0 {
+			verr.addf("gd2-connect-timeout-in-sec %d must not be negative", conf.Glusterd2ConnectTimeout)
+		}
+		if conf.Glusterd2Retries < 0 {
+			verr.addf("gd2-retries %d must not be negative", conf.Glusterd2Retries)
+		}
+		if conf.Glusterd2RetryBackoff < 0 {
+			verr.addf("gd2-retry-backoff-in-ms %d must not be negative", conf.Glusterd2RetryBackoff)
+		}
+	}
+
+	if conf.Port <= 0 || conf.Port > 65535 {
+		verr.addf("port %d is not in the range 1-65535", conf.Port)
+	}
+	if !strings.HasPrefix(conf.MetricsPath, "/") {
+		verr.addf("metrics-path %q must start with '/'", conf.MetricsPath)
+	} else if contains(reservedPaths, conf.MetricsPath) {
+		verr.addf("metrics-path %q conflicts with the paths served by the exporter", conf.MetricsPath)
+	}
+	if conf.LogFile == "" {
+		verr.addf("log-file is not set")
+	}
+	if _, err := log.ParseLevel(strings.ToLower(conf.LogLevel)); err != nil {
+		verr.addf("log-level %q is not a valid log level", conf.LogLevel)
+	}
+
+	for _, fName := range conf.CacheEnabledFuncs {
+		if !contains(cacheFuncs, fName) {
+			verr.addf("unknown function %q in cache-enabled-funcs, supported functions are: %s",
+				fName, strings.Join(cacheFuncs, ", "))
+		}
+	}
+
+	fNames := make([]string, 0, len(conf.CacheFuncTTL))
+	for fName := range conf.CacheFuncTTL {
+		fNames = append(fNames, fName)
+	}
+	sort.Strings(fNames)
+	for _, fName := range fNames {
+		if !contains(cacheFuncs, fName) {
+			verr.addf("unknown function %q in cache-func-ttl-in-sec, supported functions are: %s",
+				fName, strings.Join(cacheFuncs, ", "))
+		} else if !contains(conf.CacheEnabledFuncs, fName) {
+			verr.addf("function %q in cache-func-ttl-in-sec is not in cache-enabled-funcs", fName)
+		}
+	}
+
+	if conf.VolumeClientsIPLabelsLimit < 0 {
+		verr.addf("volume-clients-ip-labels-limit %d must not be negative", conf.VolumeClientsIPLabelsLimit)
+	}
+	if conf.BrickInternalsMaxEntries < 0 {
+		verr.addf("brick-internals-max-entries %d must not be negative", conf.BrickInternalsMaxEntries)
+	}
+}
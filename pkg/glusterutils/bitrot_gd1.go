@@ -0,0 +1,40 @@
+package glusterutils
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// BitrotScrubStatus returns the bitrot scrub status of the volume for each node
+func (g *GD1) BitrotScrubStatus(vol string) ([]ScrubNodeStatus, error) {
+	// Run gluster volume bitrot {vol} scrub status
+	out, err := g.execGluster("volume", "bitrot", vol, "scrub", "status")
+	if err != nil {
+		return nil, err
+	}
+	var scrubStatus gd1ScrubStatus
+	err = xml.Unmarshal(out, &scrubStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	outnodes := make([]ScrubNodeStatus, len(scrubStatus.Nodes))
+	for idx, node := range scrubStatus.Nodes {
+		duration, err := parseScrubDuration(node.LastScrubTook)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse scrub duration of node %s: %v", node.Name, err)
+		}
+		outnodes[idx] = ScrubNodeStatus{
+			Volume:            vol,
+			Node:              node.Name,
+			ScrubRunning:      node.ScrubRunning == "In Progress",
+			ScrubbedFiles:     node.ScrubbedFiles,
+			SkippedFiles:      node.SkippedFiles,
+			LastScrubTime:     parseScrubTime(node.LastScrubTime),
+			LastScrubDuration: duration,
+			ErrorCount:        node.ErrorCount,
+			CorruptedObjects:  node.CorruptedObjects,
+		}
+	}
+	return outnodes, nil
+}
@@ -0,0 +1,46 @@
+package glusterutils
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// BitrotScrubStatus gets the bitrot scrub status from glusterd2 using rest api
+func (g *GD2) BitrotScrubStatus(vol string) ([]ScrubNodeStatus, error) {
+	client, err := g.initRESTClient()
+	if err != nil {
+		return nil, err
+	}
+	scrubStatus, err := client.BitrotScrubStatus(vol)
+	if err != nil {
+		return nil, err
+	}
+	outnodes := make([]ScrubNodeStatus, len(scrubStatus.Nodes))
+	for idx, node := range scrubStatus.Nodes {
+		duration, err := parseScrubDuration(node.LastScrubDuration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse scrub duration of node %s: %v", node.Node, err)
+		}
+		outnode := ScrubNodeStatus{
+			Volume:            vol,
+			Node:              node.Node,
+			ScrubRunning:      node.ScrubRunning == "In Progress",
+			LastScrubTime:     parseScrubTime(node.LastScrubCompletedTime),
+			LastScrubDuration: duration,
+			CorruptedObjects:  node.CorruptedObjects,
+		}
+		// counters are reported as strings by glusterd2, ignore
+		// the values which can't be parsed
+		if v, err := strconv.ParseUint(node.NumScrubbedFiles, 10, 64); err == nil {
+			outnode.ScrubbedFiles = v
+		}
+		if v, err := strconv.ParseUint(node.NumSkippedFiles, 10, 64); err == nil {
+			outnode.SkippedFiles = v
+		}
+		if v, err := strconv.ParseUint(node.ErrorCount, 10, 64); err == nil {
+			outnode.ErrorCount = v
+		}
+		outnodes[idx] = outnode
+	}
+	return outnodes, nil
+}
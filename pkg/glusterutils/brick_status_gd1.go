@@ -20,7 +20,7 @@ func (g GD1) VolumeBrickStatus(vol string) ([]BrickStatus, error) {
 	var brickstatus []BrickStatus
 	if len(volStatus.List) > 0 {
 		for _, process := range volStatus.List[0].NodeProcesses {
-			if process.Hostname != "Self-heal Daemon" {
+			if _, isDaemon := process.daemonName(); !isDaemon {
 				brickStatusObj := BrickStatus{
 					Hostname: process.Hostname,
 					PeerID:   process.PeerID,
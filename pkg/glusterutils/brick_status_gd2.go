@@ -2,7 +2,7 @@ package glusterutils
 
 // VolumeBrickStatus gets brick status info from glusterd2 using rest api
 func (g GD2) VolumeBrickStatus(vol string) ([]BrickStatus, error) {
-	client, err := initRESTClient(g.config)
+	client, err := g.initRESTClient()
 	if err != nil {
 		return nil, err
 	}
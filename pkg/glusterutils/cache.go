@@ -2,19 +2,82 @@ package glusterutils
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
 	"github.com/gluster/gluster-prometheus/pkg/conf"
+
+	log "github.com/sirupsen/logrus"
 )
 
+//go:generate go run gen_cache.go
+
+// GCache wraps all the methods of GInterface
+var _ GInterface = (*GCache)(nil)
+
+// DefaultCacheMaxStaleness is the default maximum age
+// of the stale values served on errors
+const DefaultCacheMaxStaleness = 5 * time.Minute
+
+var errCacheType = errors.New("[CacheError] Unable to convert back to a valid return type")
+
+// CacheStat represents the cache statistics of a function
+type CacheStat struct {
+	// Hits is the no of calls served from the cache
+	Hits uint64
+	// Misses is the no of calls made to the backend
+	Misses uint64
+	// Shared is the no of calls which shared the result
+	// of an identical call in progress
+	Shared uint64
+	// NegativeHits is the no of calls served with a cached error
+	NegativeHits uint64
+	// StaleServed is the no of calls served with a stale
+	// value as the call to the backend failed
+	StaleServed uint64
+	// LastStaleAge is the age of the last stale value served
+	LastStaleAge time.Duration
+}
+
+// cacheFlight represents a call to the backend in progress,
+// identical calls wait for it and share its result
+type cacheFlight struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+}
+
+// cacheEntry is the cached result of a function for a set of arguments
+type cacheEntry struct {
+	// lock is held only to access the entry, not during the calls
+	lock     sync.Mutex
+	hasValue bool
+	value    interface{}
+	// time of the last successful call
+	updated time.Time
+	// error of the last call, and its time
+	err     error
+	errTime time.Time
+	flight  *cacheFlight
+}
+
 // GCache is a wrapper around 'GInterface' object
 type GCache struct {
-	gd                GInterface
-	ttl               time.Duration
+	gd  GInterface
+	ttl time.Duration
+	// time_to_live of the functions overriding 'ttl'
+	funcTTL map[string]time.Duration
+	// errors are cached for negativeTTL, disabled if zero
+	negativeTTL time.Duration
+	// stale values no older than maxStaleness are served
+	// if the call fails, disabled if zero
+	maxStaleness time.Duration
+	// lock guards the maps, not held during the calls
 	lock              sync.Mutex
-	lastCallValueMap  map[string]interface{}
-	lastCallTimeMap   map[string]time.Time
+	entries           map[string]*cacheEntry
+	stats             map[string]*CacheStat
 	cacheEnabledFuncs map[string]struct{}
 }
 
@@ -25,8 +88,9 @@ func NewGCacheWithTTL(gd GInterface, ttl time.Duration) *GCache {
 	gc.gd = gd
 	gc.ttl = 1 * time.Minute // default to 1 minute
 	gc.SetTTL(ttl)
-	gc.lastCallValueMap = make(map[string]interface{})
-	gc.lastCallTimeMap = make(map[string]time.Time)
+	gc.funcTTL = make(map[string]time.Duration)
+	gc.entries = make(map[string]*cacheEntry)
+	gc.stats = make(map[string]*CacheStat)
 	// functions for which caching have to be enabled
 	// are added to the below map
 	gc.cacheEnabledFuncs = make(map[string]struct{})
@@ -52,310 +116,213 @@ func (gc *GCache) SetTTL(ttl time.Duration) {
 	}
 }
 
-// EnableCacheForFuncs method will enable caching
-// for the given list of functions.
-// If the provided function is not there in the existing list, it will be ignored
-func (gc *GCache) EnableCacheForFuncs(fNames []string) {
-	for _, fName := range fNames {
-		gc.cacheEnabledFuncs[fName] = struct{}{}
+// SetFuncTTL method sets the time_to_live of the function,
+// overriding the TTL of the cache. Caching is disabled for
+// the function if ttl is ZERO
+func (gc *GCache) SetFuncTTL(funcName string, ttl time.Duration) {
+	// accepts 0 or durations in Seconds
+	if ttl == time.Duration(0) || ttl >= time.Second {
+		gc.funcTTL[funcName] = ttl
 	}
 }
 
-func (gc *GCache) timeForNewCall(funcName string, origFuncName string) (ret bool) {
-	if origFuncName == "" {
-		origFuncName = funcName
+// funcTTLOf returns the time_to_live of the function
+func (gc *GCache) funcTTLOf(funcName string) time.Duration {
+	if ttl, ok := gc.funcTTL[funcName]; ok {
+		return ttl
 	}
-	ret = true
-	// if the caching is not enabled for this function, always return true
-	// that means, it is always time for a new call
-	if _, ok := gc.cacheEnabledFuncs[origFuncName]; !ok {
-		return
-	}
-	if _, ok := gc.lastCallTimeMap[funcName]; !ok {
-		return
-	}
-	nowT := time.Now()
-	// if the last called time is BEFORE 'now - ttl', then call again
-	if gc.lastCallTimeMap[funcName].Before(nowT.Add(-gc.ttl)) {
-		return
-	}
-	return false
+	return gc.ttl
 }
 
-// EnableVolumeProfiling method wraps the GInterface.EnableVolumeProfiling call
-func (gc *GCache) EnableVolumeProfiling(vInfo Volume) error {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	const origName = "EnableVolumeProfiling"
-	// caching the result for each volume
-	var localName = origName + "-" + vInfo.ID + "-" + vInfo.Name
-	var retVal error
-	if gc.timeForNewCall(localName, origName) {
-		if retVal = gc.gd.EnableVolumeProfiling(vInfo); retVal != nil {
-			return retVal
-		}
-		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
-	}
-	return retVal
+// SetNegativeTTL method sets the duration for which the errors are
+// cached, so that a failing backend is not called on every call.
+// Negative caching is disabled if ttl is ZERO
+func (gc *GCache) SetNegativeTTL(ttl time.Duration) {
+	gc.negativeTTL = ttl
 }
 
-// HealInfo method wraps the GInterface.HealInfo call
-func (gc *GCache) HealInfo(vol string) ([]HealEntry, error) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	// adding the argument[s] also to the 'localName'
-	// as we want to cache the function call with each argument
-	// it will be wrong to cache the results for only one volume
-	// and show the same result throughout for other volumes
-	const origName = "HealInfo"
-	var localName = origName + "-" + vol
-	var retVal []HealEntry
-	var err error
-	var ok bool
-	if gc.timeForNewCall(localName, origName) {
-		if retVal, err = gc.gd.HealInfo(vol); err != nil {
-			return retVal, err
-		}
-		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
-	}
-	if retVal, ok = gc.lastCallValueMap[localName].([]HealEntry); !ok {
-		err = errors.New("[CacheError] Unable to convert back to a valid return type")
-	}
-	return retVal, err
+// SetServeStale method enables serving the last successful result
+// if the call fails, as long as it is no older than maxStaleness.
+// Serving stale results is disabled if maxStaleness is ZERO
+func (gc *GCache) SetServeStale(maxStaleness time.Duration) {
+	gc.maxStaleness = maxStaleness
 }
 
-// SplitBrainHealInfo wraps the GInterface.SplitBrainHealInfo call
-func (gc *GCache) SplitBrainHealInfo(vol string) ([]HealEntry, error) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	// adding the argument[s] also to the 'localName'
-	// as we want to cache the function call with each argument
-	// it will be wrong to cache the results for only one volume
-	// and show the same result throughout for other volumes
-	const origName = "SplitBrainHealInfo"
-	var localName = origName + "-" + vol
-	var retVal []HealEntry
-	var err error
-	var ok bool
-	if gc.timeForNewCall(localName, origName) {
-		if retVal, err = gc.gd.SplitBrainHealInfo(vol); err != nil {
-			return retVal, err
-		}
-		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
-	}
-	if retVal, ok = gc.lastCallValueMap[localName].([]HealEntry); !ok {
-		err = errors.New("[CacheError] Unable to convert back to a valid return type")
+// CacheableFuncs returns the names of the functions
+// for which caching can be enabled
+func CacheableFuncs() []string {
+	gType := reflect.TypeOf((*GInterface)(nil)).Elem()
+	fNames := make([]string, 0, gType.NumMethod())
+	for i := 0; i < gType.NumMethod(); i++ {
+		fNames = append(fNames, gType.Method(i).Name)
 	}
-	return retVal, err
+	return fNames
 }
 
-// IsLeader method wraps the GInterface.IsLeader call
-func (gc *GCache) IsLeader() (bool, error) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	const localName = "IsLeader"
-	var retVal bool
-	var err error
-	var ok bool
-	if gc.timeForNewCall(localName, localName) {
-		if retVal, err = gc.gd.IsLeader(); err != nil {
-			return retVal, err
-		}
-		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
-	}
-	if retVal, ok = gc.lastCallValueMap[localName].(bool); !ok {
-		err = errors.New("[CacheError] Unable to convert back to a valid return type")
+// EnableCacheForFuncs method will enable caching
+// for the given list of functions.
+// Unknown functions are ignored, configuration
+// is validated against 'CacheableFuncs'
+func (gc *GCache) EnableCacheForFuncs(fNames []string) {
+	for _, fName := range fNames {
+		gc.cacheEnabledFuncs[fName] = struct{}{}
 	}
-	return retVal, err
 }
 
-// LocalPeerID method wraps the GInterface.LocalPeerID call
-func (gc *GCache) LocalPeerID() (string, error) {
+// entry returns the cache entry of the key, creating it if not found
+func (gc *GCache) entry(key string) *cacheEntry {
 	gc.lock.Lock()
 	defer gc.lock.Unlock()
-	const localName = "LocalPeerID"
-	var retVal string
-	var err error
-	var ok bool
-	if gc.timeForNewCall(localName, localName) {
-		if retVal, err = gc.gd.LocalPeerID(); err != nil {
-			return retVal, err
-		}
-		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
+	e, ok := gc.entries[key]
+	if !ok {
+		e = &cacheEntry{}
+		gc.entries[key] = e
 	}
-	if retVal, ok = gc.lastCallValueMap[localName].(string); !ok {
-		err = errors.New("[CacheError] Unable to convert back to a valid return type")
-	}
-	return retVal, err
+	return e
 }
 
-// Peers method wraps the GInterface.Peers call
-func (gc *GCache) Peers() ([]Peer, error) {
+func (gc *GCache) updateStat(funcName string, update func(stat *CacheStat)) {
 	gc.lock.Lock()
 	defer gc.lock.Unlock()
-	const localName = "Peers"
-	var retVal []Peer
-	var err error
-	var ok bool
-	if gc.timeForNewCall(localName, localName) {
-		if retVal, err = gc.gd.Peers(); err != nil {
-			return retVal, err
-		}
-		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
-	}
-	if retVal, ok = gc.lastCallValueMap[localName].([]Peer); !ok {
-		err = errors.New("[CacheError] Unable to convert back to a valid return type")
+	stat, ok := gc.stats[funcName]
+	if !ok {
+		stat = &CacheStat{}
+		gc.stats[funcName] = stat
 	}
-	return retVal, err
+	update(stat)
 }
 
-// Snapshots method wraps the GInterface.Snapshots call
-func (gc *GCache) Snapshots() ([]Snapshot, error) {
+// Stats method returns the cache statistics keyed by function name
+func (gc *GCache) Stats() map[string]CacheStat {
 	gc.lock.Lock()
 	defer gc.lock.Unlock()
-	const localName = "Snapshots"
-	var retVal []Snapshot
-	var err error
-	var ok bool
-	if gc.timeForNewCall(localName, localName) {
-		if retVal, err = gc.gd.Snapshots(); err != nil {
-			return retVal, err
-		}
-		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
-	}
-	if retVal, ok = gc.lastCallValueMap[localName].([]Snapshot); !ok {
-		err = errors.New("[CacheError] Unable to convert back to a valid return type")
+	stats := make(map[string]CacheStat, len(gc.stats))
+	for funcName, stat := range gc.stats {
+		stats[funcName] = *stat
 	}
-	return retVal, err
+	return stats
 }
 
-// VolumeBrickStatus method wraps the GInterface.VolumeBrickStatus call
-func (gc *GCache) VolumeBrickStatus(vol string) ([]BrickStatus, error) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	// caching the results for each volume
-	const origName = "VolumeBrickStatus"
-	var localName = origName + "-" + vol
-	var retVal []BrickStatus
-	var err error
-	var ok bool
-	if gc.timeForNewCall(localName, origName) {
-		if retVal, err = gc.gd.VolumeBrickStatus(vol); err != nil {
-			return retVal, err
-		}
-		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
-	}
-	if retVal, ok = gc.lastCallValueMap[localName].([]BrickStatus); !ok {
-		err = errors.New("[CacheError] Unable to convert back to a valid return type")
+// CacheStats returns the cache statistics keyed by function
+// name, nil if the gluster object is not cached
+func CacheStats(gi GInterface) map[string]CacheStat {
+	if gc, ok := gi.(*GCache); ok {
+		return gc.Stats()
 	}
-	return retVal, err
+	return nil
 }
 
-// VolumeInfo method wraps the GInterface.VolumeInfo call
-func (gc *GCache) VolumeInfo() ([]Volume, error) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	const localName = "VolumeInfo"
-	var retVal []Volume
-	var err error
-	var ok bool
-	if gc.timeForNewCall(localName, localName) {
-		if retVal, err = gc.gd.VolumeInfo(); err != nil {
-			return retVal, err
-		}
-		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
+// staleValue returns the last successful result of the entry, if serving
+// stale results is enabled and it is not too old. Called with e.lock held
+func (gc *GCache) staleValue(funcName string, e *cacheEntry) (interface{}, bool) {
+	if gc.maxStaleness == 0 || !e.hasValue {
+		return nil, false
 	}
-	if retVal, ok = gc.lastCallValueMap[localName].([]Volume); !ok {
-		err = errors.New("[CacheError] Unable to convert back to a valid return type")
+	age := time.Since(e.updated)
+	if age > gc.maxStaleness {
+		return nil, false
 	}
-	return retVal, err
+	gc.updateStat(funcName, func(stat *CacheStat) {
+		stat.StaleServed++
+		stat.LastStaleAge = age
+	})
+	return e.value, true
 }
 
-// VolumeStatus method wraps the GInterface.VolumeStatus call
-func (gc *GCache) VolumeStatus() ([]VolumeStatus, error) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	// caching the results for each volume
-	const localName = "VolumeProfileStatus"
-	var retVal []VolumeStatus
-	var err error
-	var ok bool
-	if gc.timeForNewCall(localName, localName) {
-		if retVal, err = gc.gd.VolumeStatus(); err != nil {
-			return retVal, err
+// call returns the cached result of the function for the key, calls
+// 'fn' if the result is not cached or expired. 'funcName' is the name
+// of the function, and the key identifies its arguments. Only one call
+// is made for a key at a time, identical calls share its result.
+func (gc *GCache) call(funcName string, key string, fn func() (interface{}, error)) (interface{}, error) {
+	// if the caching is not enabled for this function,
+	// it is always time for a new call
+	ttl := gc.funcTTLOf(funcName)
+	if _, ok := gc.cacheEnabledFuncs[funcName]; !ok || ttl == 0 {
+		return fn()
+	}
+
+	e := gc.entry(key)
+	e.lock.Lock()
+	if e.hasValue && time.Since(e.updated) < ttl {
+		val := e.value
+		e.lock.Unlock()
+		gc.updateStat(funcName, func(stat *CacheStat) { stat.Hits++ })
+		return val, nil
+	}
+	if e.err != nil && time.Since(e.errTime) < gc.negativeTTL {
+		val, ok := gc.staleValue(funcName, e)
+		err := e.err
+		e.lock.Unlock()
+		if ok {
+			return val, nil
 		}
-		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
+		gc.updateStat(funcName, func(stat *CacheStat) { stat.NegativeHits++ })
+		return nil, err
 	}
-	if retVal, ok = gc.lastCallValueMap[localName].([]VolumeStatus); !ok {
-		err = errors.New("[CacheError] Unable to convert back to a valid return type")
+	if f := e.flight; f != nil {
+		e.lock.Unlock()
+		gc.updateStat(funcName, func(stat *CacheStat) { stat.Shared++ })
+		<-f.done
+		return f.val, f.err
 	}
-	return retVal, err
-}
+	f := &cacheFlight{done: make(chan struct{})}
+	e.flight = f
+	e.lock.Unlock()
 
-// VolumeProfileInfo method wraps the GInterface.VolumeProfileInfo call
-func (gc *GCache) VolumeProfileInfo(vol string) ([]ProfileInfo, error) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	// caching the results for each volume
-	const origName = "VolumeProfileInfo"
-	var localName = origName + "-" + vol
-	var retVal []ProfileInfo
-	var err error
-	var ok bool
-	if gc.timeForNewCall(localName, origName) {
-		if retVal, err = gc.gd.VolumeProfileInfo(vol); err != nil {
-			return retVal, err
-		}
+	gc.updateStat(funcName, func(stat *CacheStat) { stat.Misses++ })
+	f.val, f.err = fn()
+
+	e.lock.Lock()
+	if f.err == nil {
 		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
+		e.hasValue = true
+		e.value = f.val
+		e.updated = time.Now()
+		e.err = nil
+	} else {
+		e.err = f.err
+		e.errTime = time.Now()
+		if val, ok := gc.staleValue(funcName, e); ok {
+			log.WithError(f.err).WithField("key", key).Debug("Call failed, serving the stale value from the cache")
+			f.val, f.err = val, nil
+		}
 	}
-	if retVal, ok = gc.lastCallValueMap[localName].([]ProfileInfo); !ok {
-		err = errors.New("[CacheError] Unable to convert back to a valid return type")
+	e.flight = nil
+	e.lock.Unlock()
+	close(f.done)
+	return f.val, f.err
+}
+
+// cachedCall calls the function through the cache and converts back the
+// result. Used by the generated wrappers of all the GInterface methods.
+func cachedCall[T any](gc *GCache, funcName string, key string, fn func() (T, error)) (T, error) {
+	val, err := gc.call(funcName, key, func() (interface{}, error) {
+		return fn()
+	})
+	retVal, ok := val.(T)
+	if !ok && err == nil {
+		err = errCacheType
 	}
 	return retVal, err
 }
 
-// VolumeInfo method wraps the GInterface.VolumeInfo call
-func (gc *GCache) Quotas() ([]Quota, error) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	const localName = "Quotas"
-	var retVal []Quota
-	var err error
-	var ok bool
-	if gc.timeForNewCall(localName, localName) {
-		if retVal, err = gc.gd.Quotas(); err != nil {
-			return retVal, err
+// cacheKey returns the cache key of the function call, adding the
+// arguments also to the key as the result of each argument is cached
+// separately. It will be wrong to cache the results for only one
+// volume and show the same result throughout for other volumes
+func cacheKey(funcName string, args ...interface{}) string {
+	key := funcName
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			key += "-" + v
+		case Volume:
+			key += "-" + v.ID + "-" + v.Name
+		default:
+			key += "-" + fmt.Sprint(v)
 		}
-		// reset the last called time only on a successful call
-		gc.lastCallTimeMap[localName] = time.Now()
-		gc.lastCallValueMap[localName] = retVal
 	}
-	if retVal, ok = gc.lastCallValueMap[localName].([]Quota); !ok {
-		err = errors.New("[CacheError] Unable to convert back to a valid return type")
-	}
-	return retVal, err
+	return key
 }
 
 // GConfig implements GConfigInterface
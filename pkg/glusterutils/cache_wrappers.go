@@ -0,0 +1,137 @@
+// Code generated by gen_cache.go; DO NOT EDIT.
+
+package glusterutils
+
+// Peers method wraps the GInterface.Peers call
+func (gc *GCache) Peers() ([]Peer, error) {
+	return cachedCall(gc, "Peers", cacheKey("Peers"), func() ([]Peer, error) {
+		return gc.gd.Peers()
+	})
+}
+
+// LocalPeerID method wraps the GInterface.LocalPeerID call
+func (gc *GCache) LocalPeerID() (string, error) {
+	return cachedCall(gc, "LocalPeerID", cacheKey("LocalPeerID"), func() (string, error) {
+		return gc.gd.LocalPeerID()
+	})
+}
+
+// IsLeader method wraps the GInterface.IsLeader call
+func (gc *GCache) IsLeader() (bool, error) {
+	return cachedCall(gc, "IsLeader", cacheKey("IsLeader"), func() (bool, error) {
+		return gc.gd.IsLeader()
+	})
+}
+
+// HealInfo method wraps the GInterface.HealInfo call
+func (gc *GCache) HealInfo(vol string) ([]HealEntry, error) {
+	return cachedCall(gc, "HealInfo", cacheKey("HealInfo", vol), func() ([]HealEntry, error) {
+		return gc.gd.HealInfo(vol)
+	})
+}
+
+// SplitBrainHealInfo method wraps the GInterface.SplitBrainHealInfo call
+func (gc *GCache) SplitBrainHealInfo(vol string) ([]HealEntry, error) {
+	return cachedCall(gc, "SplitBrainHealInfo", cacheKey("SplitBrainHealInfo", vol), func() ([]HealEntry, error) {
+		return gc.gd.SplitBrainHealInfo(vol)
+	})
+}
+
+// VolumeInfo method wraps the GInterface.VolumeInfo call
+func (gc *GCache) VolumeInfo() ([]Volume, error) {
+	return cachedCall(gc, "VolumeInfo", cacheKey("VolumeInfo"), func() ([]Volume, error) {
+		return gc.gd.VolumeInfo()
+	})
+}
+
+// Quotas method wraps the GInterface.Quotas call
+func (gc *GCache) Quotas() ([]Quota, error) {
+	return cachedCall(gc, "Quotas", cacheKey("Quotas"), func() ([]Quota, error) {
+		return gc.gd.Quotas()
+	})
+}
+
+// Snapshots method wraps the GInterface.Snapshots call
+func (gc *GCache) Snapshots() ([]Snapshot, error) {
+	return cachedCall(gc, "Snapshots", cacheKey("Snapshots"), func() ([]Snapshot, error) {
+		return gc.gd.Snapshots()
+	})
+}
+
+// VolumeProfileInfo method wraps the GInterface.VolumeProfileInfo call
+func (gc *GCache) VolumeProfileInfo(vol string) ([]ProfileInfo, error) {
+	return cachedCall(gc, "VolumeProfileInfo", cacheKey("VolumeProfileInfo", vol), func() ([]ProfileInfo, error) {
+		return gc.gd.VolumeProfileInfo(vol)
+	})
+}
+
+// VolumeBrickStatus method wraps the GInterface.VolumeBrickStatus call
+func (gc *GCache) VolumeBrickStatus(vol string) ([]BrickStatus, error) {
+	return cachedCall(gc, "VolumeBrickStatus", cacheKey("VolumeBrickStatus", vol), func() ([]BrickStatus, error) {
+		return gc.gd.VolumeBrickStatus(vol)
+	})
+}
+
+// EnableVolumeProfiling method wraps the GInterface.EnableVolumeProfiling call
+func (gc *GCache) EnableVolumeProfiling(volinfo Volume) error {
+	_, err := cachedCall(gc, "EnableVolumeProfiling", cacheKey("EnableVolumeProfiling", volinfo), func() (struct{}, error) {
+		return struct{}{}, gc.gd.EnableVolumeProfiling(volinfo)
+	})
+	return err
+}
+
+// VolumeStatus method wraps the GInterface.VolumeStatus call
+func (gc *GCache) VolumeStatus() ([]VolumeStatus, error) {
+	return cachedCall(gc, "VolumeStatus", cacheKey("VolumeStatus"), func() ([]VolumeStatus, error) {
+		return gc.gd.VolumeStatus()
+	})
+}
+
+// VolumeClients method wraps the GInterface.VolumeClients call
+func (gc *GCache) VolumeClients(vol string) ([]BrickClients, error) {
+	return cachedCall(gc, "VolumeClients", cacheKey("VolumeClients", vol), func() ([]BrickClients, error) {
+		return gc.gd.VolumeClients(vol)
+	})
+}
+
+// VolumeMemStatus method wraps the GInterface.VolumeMemStatus call
+func (gc *GCache) VolumeMemStatus(vol string) ([]BrickMemStatus, error) {
+	return cachedCall(gc, "VolumeMemStatus", cacheKey("VolumeMemStatus", vol), func() ([]BrickMemStatus, error) {
+		return gc.gd.VolumeMemStatus(vol)
+	})
+}
+
+// VolumeInodeStatus method wraps the GInterface.VolumeInodeStatus call
+func (gc *GCache) VolumeInodeStatus(vol string) ([]BrickInodeStatus, error) {
+	return cachedCall(gc, "VolumeInodeStatus", cacheKey("VolumeInodeStatus", vol), func() ([]BrickInodeStatus, error) {
+		return gc.gd.VolumeInodeStatus(vol)
+	})
+}
+
+// VolumeDaemonStatus method wraps the GInterface.VolumeDaemonStatus call
+func (gc *GCache) VolumeDaemonStatus() ([]DaemonStatus, error) {
+	return cachedCall(gc, "VolumeDaemonStatus", cacheKey("VolumeDaemonStatus"), func() ([]DaemonStatus, error) {
+		return gc.gd.VolumeDaemonStatus()
+	})
+}
+
+// BitrotScrubStatus method wraps the GInterface.BitrotScrubStatus call
+func (gc *GCache) BitrotScrubStatus(vol string) ([]ScrubNodeStatus, error) {
+	return cachedCall(gc, "BitrotScrubStatus", cacheKey("BitrotScrubStatus", vol), func() ([]ScrubNodeStatus, error) {
+		return gc.gd.BitrotScrubStatus(vol)
+	})
+}
+
+// LocalOpVersion method wraps the GInterface.LocalOpVersion call
+func (gc *GCache) LocalOpVersion() (int, error) {
+	return cachedCall(gc, "LocalOpVersion", cacheKey("LocalOpVersion"), func() (int, error) {
+		return gc.gd.LocalOpVersion()
+	})
+}
+
+// ClusterOpVersion method wraps the GInterface.ClusterOpVersion call
+func (gc *GCache) ClusterOpVersion() (ClusterOpVersion, error) {
+	return cachedCall(gc, "ClusterOpVersion", cacheKey("ClusterOpVersion"), func() (ClusterOpVersion, error) {
+		return gc.gd.ClusterOpVersion()
+	})
+}
@@ -0,0 +1,43 @@
+package glusterutils
+
+import (
+	"encoding/xml"
+)
+
+// VolumeClients returns the clients connected to each brick of the volume
+func (g *GD1) VolumeClients(vol string) ([]BrickClients, error) {
+	// Run gluster volume status {vol} clients
+	out, err := g.execGluster("volume", "status", vol, "clients")
+	if err != nil {
+		return nil, err
+	}
+	var volStatus gd1VolumeStatus
+	err = xml.Unmarshal(out, &volStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	var brickClients []BrickClients
+	if len(volStatus.List) > 0 {
+		for _, process := range volStatus.List[0].NodeProcesses {
+			obj := BrickClients{
+				Hostname:    process.Hostname,
+				PeerID:      process.PeerID,
+				Path:        process.Path,
+				Volume:      vol,
+				ClientCount: process.ClientsStatus.ClientCount,
+			}
+			obj.Clients = make([]BrickClient, len(process.ClientsStatus.Clients))
+			for idx, client := range process.ClientsStatus.Clients {
+				obj.Clients[idx] = BrickClient{
+					Hostname:     client.Hostname,
+					BytesRead:    client.BytesRead,
+					BytesWritten: client.BytesWrite,
+					OpVersion:    client.OpVersion,
+				}
+			}
+			brickClients = append(brickClients, obj)
+		}
+	}
+	return brickClients, nil
+}
@@ -0,0 +1,8 @@
+package glusterutils
+
+import "errors"
+
+// VolumeClients returns the clients connected to each brick of the volume
+func (g *GD2) VolumeClients(vol string) ([]BrickClients, error) {
+	return nil, errors.New("not implemented")
+}
@@ -1,25 +1,29 @@
 package glusterutils
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gluster/gluster-prometheus/pkg/conf"
 	"github.com/gluster/glusterd2/pkg/restclient"
 )
 
-func initRESTClient(config *conf.GConfig) (*restclient.Client, error) {
-	client, err := restclient.New(
-		config.Glusterd2Endpoint,
-		config.Glusterd2User,
-		config.Glusterd2Secret,
-		config.Glusterd2Cacert,
-		config.Glusterd2Insecure,
-	)
-	if err != nil {
-		return nil, err
+// initRESTClient returns a glusterd2 REST client using the connection
+// pool of the GD2 object. 'restclient.Client' records the last error
+// response and is not safe for concurrent use, so only the lightweight
+// client is created per call around the long-lived HTTP client.
+func (g GD2) initRESTClient() (*restclient.Client, error) {
+	if g.endpoints.err != nil {
+		return nil, g.endpoints.err
 	}
-	client.SetTimeout(time.Duration(config.Timeout) * time.Second)
-	return client, nil
+	return restclient.NewClientWithOpts(
+		restclient.WithBaseURL(g.endpoints.baseURL()),
+		restclient.WithHTTPClient(g.httpClient),
+		restclient.WithUsername(g.config.Glusterd2User),
+		restclient.WithPassword(g.config.Glusterd2Secret),
+	)
 }
 
 func setDefaultConfig(config *conf.GConfig) {
@@ -35,4 +39,51 @@ func setDefaultConfig(config *conf.GConfig) {
 	if config.Glusterd2Endpoint == "" {
 		config.Glusterd2Endpoint = "http://localhost:24007"
 	}
+	if config.GlusterCLIRetryBackoff == 0 {
+		config.GlusterCLIRetryBackoff = 1000
+	}
+	if config.Glusterd2ConnectTimeout == 0 {
+		config.Glusterd2ConnectTimeout = 5
+	}
+	if config.Glusterd2RetryBackoff == 0 {
+		config.Glusterd2RetryBackoff = 500
+	}
+}
+
+// scrubTimeLayout is the layout of last completed scrub time
+// reported by the bitrot scrubber (always in UTC)
+const scrubTimeLayout = "2006-01-02 15:04:05"
+
+// parseScrubTime parses the last completed scrub time, zero time is
+// returned if the scrubber has not completed any scrub yet
+func parseScrubTime(s string) time.Time {
+	t, err := time.Parse(scrubTimeLayout, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// parseScrubDuration parses the scrub duration reported in
+// D:H:M:S format (Ex: 0:1:20:5)
+func parseScrubDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	parts := strings.Split(s, ":")
+	units := []time.Duration{time.Second, time.Minute, time.Hour, 24 * time.Hour}
+	if len(parts) > len(units) {
+		return 0, fmt.Errorf("invalid scrub duration %q", s)
+	}
+	var d time.Duration
+	// parse from the right most field, which is always seconds
+	for idx := range parts {
+		v, err := strconv.ParseUint(parts[len(parts)-1-idx], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid scrub duration %q: %v", s, err)
+		}
+		d += time.Duration(v) * units[idx]
+	}
+	return d, nil
 }
@@ -8,7 +8,7 @@ import (
 
 // EnableVolumeProfiling enables profiling for a volume
 func (g *GD2) EnableVolumeProfiling(volume Volume) error {
-	client, err := initRESTClient(g.config)
+	client, err := g.initRESTClient()
 	if err != nil {
 		return err
 	}
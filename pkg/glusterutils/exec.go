@@ -0,0 +1,233 @@
+package glusterutils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gluster/gluster-prometheus/pkg/conf"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// patterns of the gluster CLI errors reported when glusterd
+// is busy with another transaction, the commands are retried
+var cliRetryPatterns = []string{
+	"Another transaction is in progress",
+	"Locking failed",
+}
+
+var opErrstrPattern = regexp.MustCompile(`<opErrstr>([^<]*)</opErrstr>`)
+
+// CLIStat represents the statistics of a gluster CLI subcommand
+type CLIStat struct {
+	// Commands is the no of commands run, including the retries
+	Commands uint64
+	// Retries is the no of commands retried as glusterd was busy
+	Retries uint64
+	// Timeouts is the no of commands killed on timeout
+	Timeouts uint64
+	// QueueWait is the total time the commands waited to be run
+	QueueWait time.Duration
+}
+
+var (
+	cliStatsLock sync.Mutex
+	// statistics of the gluster CLI, keyed by subcommand
+	cliStats = make(map[string]*CLIStat)
+	// no of commands waiting in the queue
+	cliQueueLength int
+)
+
+// CLIStats returns the statistics of the gluster CLI commands
+// run so far, keyed by subcommand (Ex: "volume status")
+func CLIStats() map[string]CLIStat {
+	cliStatsLock.Lock()
+	defer cliStatsLock.Unlock()
+	stats := make(map[string]CLIStat, len(cliStats))
+	for subcmd, stat := range cliStats {
+		stats[subcmd] = *stat
+	}
+	return stats
+}
+
+// CLIQueueLength returns the no of gluster CLI
+// commands waiting in the queue to be run
+func CLIQueueLength() int {
+	cliStatsLock.Lock()
+	defer cliStatsLock.Unlock()
+	return cliQueueLength
+}
+
+// updateCLIStat updates the statistics of the subcommand
+func updateCLIStat(subcmd string, update func(stat *CLIStat)) {
+	cliStatsLock.Lock()
+	defer cliStatsLock.Unlock()
+	stat, ok := cliStats[subcmd]
+	if !ok {
+		stat = &CLIStat{}
+		cliStats[subcmd] = stat
+	}
+	update(stat)
+}
+
+func addCLIQueueLength(delta int) {
+	cliStatsLock.Lock()
+	defer cliStatsLock.Unlock()
+	cliQueueLength += delta
+}
+
+// cliExecutor limits the no of gluster CLI commands run concurrently,
+// glusterd serializes most of the commands behind a cluster wide lock.
+// Commands failed as glusterd is busy are retried with exponential backoff.
+type cliExecutor struct {
+	// nil if the no of concurrent commands is not limited
+	sem     chan struct{}
+	retries int
+	backoff time.Duration
+}
+
+// newCLIExecutor creates the executor shared by all the collectors
+func newCLIExecutor(config *conf.GConfig) *cliExecutor {
+	e := &cliExecutor{
+		retries: config.GlusterCLIRetries,
+		backoff: time.Duration(config.GlusterCLIRetryBackoff) * time.Millisecond,
+	}
+	if config.GlusterCLIConcurrency > 0 {
+		e.sem = make(chan struct{}, config.GlusterCLIConcurrency)
+	}
+	return e
+}
+
+// acquire waits for a slot to run a command, returns the time waited
+func (e *cliExecutor) acquire(ctx context.Context) (time.Duration, error) {
+	if e.sem == nil {
+		return 0, nil
+	}
+	waitStart := time.Now()
+	addCLIQueueLength(1)
+	defer addCLIQueueLength(-1)
+	select {
+	case e.sem <- struct{}{}:
+		return time.Since(waitStart), nil
+	case <-ctx.Done():
+		return time.Since(waitStart), ctx.Err()
+	}
+}
+
+func (e *cliExecutor) release() {
+	if e.sem != nil {
+		<-e.sem
+	}
+}
+
+// isGlusterdBusy returns true if the command failed as
+// glusterd is busy with another transaction
+func isGlusterdBusy(out []byte, err error) bool {
+	var msgs []string
+	if err != nil {
+		msgs = append(msgs, err.Error())
+	}
+	if m := opErrstrPattern.FindSubmatch(out); m != nil {
+		msgs = append(msgs, string(m[1]))
+	}
+	for _, msg := range msgs {
+		for _, pattern := range cliRetryPatterns {
+			if strings.Contains(msg, pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// run runs the command once a slot is available, and retries
+// it if glusterd is busy. 'fn' runs the command once.
+func (e *cliExecutor) run(ctx context.Context, subcmd string,
+	fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if e == nil {
+		return fn(ctx)
+	}
+	backoff := e.backoff
+	for attempt := 0; ; attempt++ {
+		wait, err := e.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out, err := fn(ctx)
+		e.release()
+		updateCLIStat(subcmd, func(stat *CLIStat) {
+			stat.Commands++
+			stat.QueueWait += wait
+		})
+
+		if attempt >= e.retries || !isGlusterdBusy(out, err) {
+			return out, err
+		}
+		updateCLIStat(subcmd, func(stat *CLIStat) {
+			stat.Retries++
+		})
+		log.WithError(err).WithFields(log.Fields{
+			"command": subcmd,
+			"attempt": attempt + 1,
+			"backoff": backoff,
+		}).Debug("glusterd is busy, retrying the command")
+		select {
+		case <-ctx.Done():
+			return out, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// cliSubcommand returns the subcommand of the gluster CLI arguments,
+// the first two arguments never include the volume name
+func cliSubcommand(args []string) string {
+	if len(args) > 2 {
+		args = args[:2]
+	}
+	return strings.Join(args, " ")
+}
+
+// runCommand runs the command in its own process group, the whole process
+// group is killed if the context is done before the command completes.
+// Stdout is returned along with the error, and stderr is included in the
+// error if the command fails.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...) // #nosec
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	waitC := make(chan error, 1)
+	go func() {
+		waitC <- cmd.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-waitC:
+	case <-ctx.Done():
+		// negative pid kills all the processes of the group
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-waitC
+		return stdout.Bytes(), ctx.Err()
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+	}
+	return stdout.Bytes(), err
+}
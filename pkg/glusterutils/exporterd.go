@@ -2,6 +2,7 @@ package glusterutils
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"io"
 	"os"
@@ -70,18 +71,37 @@ func (g *GD2) IsLeader() (bool, error) {
 
 // MakeGluster returns respective gluster obj based on configuration
 func MakeGluster(expConf *conf.Config) (gi GInterface) {
+	return MakeGlusterContext(context.Background(), expConf)
+}
+
+// MakeGlusterContext returns the gluster obj similar to 'MakeGluster',
+// the gluster CLI commands running or waiting to be run are cancelled
+// once the context is done. glusterd2 requests are limited only by the
+// configured timeout.
+func MakeGlusterContext(ctx context.Context, expConf *conf.Config) (gi GInterface) {
 	gConfig := expConf.GConfig()
 	if gConfig == nil {
 		return nil
 	}
 	setDefaultConfig(gConfig)
-	gi = &GD2{config: gConfig}
+	gi = newGD2(gConfig)
 	if gConfig.GlusterMgmt == "" || gConfig.GlusterMgmt == glusterconsts.MgmtGlusterd {
-		gi = &GD1{config: gConfig}
+		gi = &GD1{config: gConfig, executor: newCLIExecutor(gConfig), ctx: ctx}
 	}
 	cacheTTL := time.Duration(expConf.CacheTTL) * time.Second
 	cachedGI := NewGCacheWithTTL(gi, cacheTTL)
 	cachedGI.EnableCacheForFuncs(expConf.CacheEnabledFuncs)
+	for fName, ttl := range expConf.CacheFuncTTL {
+		cachedGI.SetFuncTTL(fName, time.Duration(ttl)*time.Second)
+	}
+	cachedGI.SetNegativeTTL(time.Duration(expConf.CacheNegativeTTL) * time.Second)
+	if expConf.CacheServeStale {
+		maxStaleness := DefaultCacheMaxStaleness
+		if expConf.CacheMaxStaleness > 0 {
+			maxStaleness = time.Duration(expConf.CacheMaxStaleness) * time.Second
+		}
+		cachedGI.SetServeStale(maxStaleness)
+	}
 	return cachedGI
 }
 
@@ -156,14 +176,18 @@ func (g *GD2) GConfig() *conf.GConfig {
 
 // GetGlusterVersion gets the glusterfs version
 func GetGlusterVersion() (string, error) {
-	cmd := "glusterfs --version | head -1"
+	cmd := "glusterfs --version"
 	bytes, err := ExecuteCmd(cmd)
 	if err != nil {
 		return "", err
 	}
-	stdout := string(bytes[:])
+	// First line of the output is "glusterfs <version>"
+	stdout := strings.SplitN(string(bytes[:]), "\n", 2)[0]
 	fields := strings.Fields(stdout)
-	return fields[1], err
+	if len(fields) < 2 {
+		return "", errors.New("unable to find glusterfs version")
+	}
+	return fields[1], nil
 }
 
 // ExecuteCmd enables to execute system cmds and returns stdout, err
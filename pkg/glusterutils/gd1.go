@@ -1,12 +1,13 @@
 package glusterutils
 
 import (
+	"context"
 	"encoding/xml"
+	"time"
 
 	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
 
 	"fmt"
-	"os/exec"
 )
 
 type healBricks struct {
@@ -167,6 +168,59 @@ type gd1ProtocolPorts struct {
 	RDMAPort string `xml:"rdma"`
 }
 
+type gd1Client struct {
+	Hostname   string `xml:"hostname"`
+	BytesRead  uint64 `xml:"bytesRead"`
+	BytesWrite uint64 `xml:"bytesWrite"`
+	OpVersion  int    `xml:"opVersion"`
+}
+
+type gd1ClientsStatus struct {
+	ClientCount int         `xml:"clientCount"`
+	Clients     []gd1Client `xml:"client"`
+}
+
+type gd1MallInfo struct {
+	Arena    uint64 `xml:"arena"`
+	Ordblks  uint64 `xml:"ordblks"`
+	Smblks   uint64 `xml:"smblks"`
+	Hblks    uint64 `xml:"hblks"`
+	Hblkhd   uint64 `xml:"hblkhd"`
+	Usmblks  uint64 `xml:"usmblks"`
+	Fsmblks  uint64 `xml:"fsmblks"`
+	Uordblks uint64 `xml:"uordblks"`
+	Fordblks uint64 `xml:"fordblks"`
+	Keepcost uint64 `xml:"keepcost"`
+}
+
+type gd1MemPool struct {
+	Name         string `xml:"name"`
+	HotCount     uint64 `xml:"hotCount"`
+	ColdCount    uint64 `xml:"coldCount"`
+	PaddedSizeOf uint64 `xml:"padddedSizeOf"` // sic, as in gluster cli output
+	AllocCount   uint64 `xml:"allocCount"`
+	MaxAlloc     uint64 `xml:"maxAlloc"`
+	PoolMisses   uint64 `xml:"poolMisses"`
+	MaxStdAlloc  uint64 `xml:"maxStdAlloc"`
+}
+
+type gd1MemStatus struct {
+	MallInfo gd1MallInfo  `xml:"mallinfo"`
+	MemPools []gd1MemPool `xml:"mempool>pool"`
+}
+
+// gd1InodeTable is the inode table of a connection, nested
+// in the <itable> element of each <connection>
+type gd1InodeTable struct {
+	ActiveSize uint64 `xml:"itable>activeSize"`
+	LRUSize    uint64 `xml:"itable>lruSize"`
+	PurgeSize  uint64 `xml:"itable>purgeSize"`
+}
+
+type gd1InodeStatus struct {
+	Connections []gd1InodeTable `xml:"connection"`
+}
+
 type gd1Process struct {
 	Hostname      string           `xml:"hostname"`
 	Path          string           `xml:"path"`
@@ -179,6 +233,9 @@ type gd1Process struct {
 	InodesFree    uint64           `xml:"inodesFree"`
 	SizeTotal     uint64           `xml:"sizeTotal"`
 	SizeFree      uint64           `xml:"sizeFree"`
+	ClientsStatus gd1ClientsStatus `xml:"clientsStatus"`
+	MemStatus     gd1MemStatus     `xml:"memStatus"`
+	InodeStatus   gd1InodeStatus   `xml:"inodeStatus"`
 }
 
 type gd1VolumeStatusInfo struct {
@@ -192,6 +249,33 @@ type gd1VolumeStatus struct {
 	List    []gd1VolumeStatusInfo `xml:"volStatus>volumes>volume"`
 }
 
+type gd1ScrubNode struct {
+	Name             string   `xml:"nodeName"`
+	ScrubRunning     string   `xml:"scrubRunning"`
+	ScrubbedFiles    uint64   `xml:"numberOfScrubbedFiles"`
+	SkippedFiles     uint64   `xml:"numberOfSkippedFiles"`
+	LastScrubTime    string   `xml:"lastCompletedScrubTime"`
+	LastScrubTook    string   `xml:"durationOfLastScrub"`
+	ErrorCount       uint64   `xml:"errorCount"`
+	CorruptedObjects []string `xml:"bitrot_error>objects"`
+}
+
+type gd1ScrubStatus struct {
+	XMLName xml.Name       `xml:"cliOutput"`
+	VolName string         `xml:"volBitRot>volName"`
+	Nodes   []gd1ScrubNode `xml:"volBitRot>node"`
+}
+
+type gd1VolGetOpt struct {
+	Option string `xml:"Option"`
+	Value  string `xml:"Value"`
+}
+
+type gd1VolGetOpts struct {
+	XMLName xml.Name       `xml:"cliOutput"`
+	Opts    []gd1VolGetOpt `xml:"volGetopts>Opt"`
+}
+
 func (t *gd1Transport) String() string {
 	// 0 - tcp
 	// 1 - rdma
@@ -204,6 +288,24 @@ func (t *gd1Transport) String() string {
 	return "tcp,rdma"
 }
 
+// gd1DaemonNames maps the process names listed in volume status
+// to the short daemon names
+var gd1DaemonNames = map[string]string{
+	glusterconsts.DaemonNameSelfHeal: glusterconsts.DaemonSelfHeal,
+	glusterconsts.DaemonNameNFS:      glusterconsts.DaemonNFS,
+	glusterconsts.DaemonNameQuota:    glusterconsts.DaemonQuota,
+	glusterconsts.DaemonNameBitrot:   glusterconsts.DaemonBitrot,
+	glusterconsts.DaemonNameScrubber: glusterconsts.DaemonScrubber,
+	glusterconsts.DaemonNameSnapshot: glusterconsts.DaemonSnapshot,
+}
+
+// daemonName returns the short daemon name, if the volume status
+// process is an auxiliary daemon instead of a brick
+func (p *gd1Process) daemonName() (string, bool) {
+	name, ok := gd1DaemonNames[p.Hostname]
+	return name, ok
+}
+
 func getSubvolType(voltype string) string {
 	switch voltype {
 	case glusterconsts.VolumeTypeDistReplicate:
@@ -226,8 +328,15 @@ func getSubvolBricksCount(replicaCount int, disperseCount int) int {
 	return 1
 }
 
-// execGluster runs `gluster` with --xml --remote-host=<...> and the args provided
+// execGluster runs `gluster` with --xml --remote-host=<...> and the args
+// provided using the shared executor, the command is killed if it doesn't
+// complete within the configured timeout or before the GD1 context is done
 func (g *GD1) execGluster(args ...string) ([]byte, error) {
+	ctx := g.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	subcmd := cliSubcommand(args)
 	// always request output in XML format
 	args = append(args, "--xml")
 	// grab remote host from config
@@ -236,5 +345,23 @@ func (g *GD1) execGluster(args ...string) ([]byte, error) {
 	} else if g.config.GlusterRemoteHost != "" {
 		args = append(args, fmt.Sprintf("--remote-host=%s", g.config.GlusterRemoteHost))
 	}
-	return exec.Command(g.config.GlusterCmd, args...).Output() // #nosec
+	return g.executor.run(ctx, subcmd, func(ctx context.Context) ([]byte, error) {
+		timeout := time.Duration(g.config.Timeout) * time.Second
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		out, err := runCommand(ctx, g.config.GlusterCmd, args...)
+		if err == context.DeadlineExceeded {
+			updateCLIStat(subcmd, func(stat *CLIStat) {
+				stat.Timeouts++
+			})
+			return out, fmt.Errorf("gluster %s timed out after %s", subcmd, timeout)
+		}
+		if err != nil {
+			return out, fmt.Errorf("gluster %s failed: %v", subcmd, err)
+		}
+		return out, nil
+	})
 }
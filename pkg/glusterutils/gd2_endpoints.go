@@ -0,0 +1,371 @@
+package glusterutils
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gluster/gluster-prometheus/pkg/conf"
+	"github.com/gluster/glusterd2/pkg/restclient"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// gd2FailbackInterval is the minimum interval between the health checks
+// of the preferred endpoints, when a fail over endpoint is in use
+const gd2FailbackInterval = time.Minute
+
+// gd2HealthCheckTimeout is the timeout of an endpoint health check
+const gd2HealthCheckTimeout = 5 * time.Second
+
+// GD2EndpointStatus represents the status of a glusterd2 endpoint
+type GD2EndpointStatus struct {
+	Endpoint string
+	// Active is true if the endpoint is the one in use
+	Active bool
+	// Up is the result of the last request or health check
+	Up bool
+	// Failovers is the no of times switched over to this endpoint
+	Failovers uint64
+}
+
+// gd2Endpoints is a 'http.RoundTripper' sending the glusterd2 REST
+// requests to the active endpoint. Idempotent requests are failed over
+// to the next healthy endpoint on connection errors and 5xx responses,
+// the others only if the connection to the endpoint fails. Endpoints
+// are preferred in the configured order, and the preferred ones are
+// health checked periodically to fail back. Idempotent requests failed
+// on all the endpoints are retried with exponential backoff.
+type gd2Endpoints struct {
+	lock      sync.Mutex
+	urls      []*url.URL
+	active    int
+	up        []bool
+	failovers []uint64
+	// last time the preferred endpoints were health checked
+	lastFailback time.Time
+	retries      int
+	backoff      time.Duration
+	// transport to send the requests
+	next http.RoundTripper
+	err  error
+}
+
+// newGD2 creates the GD2 object with a long-lived
+// HTTP client for the configured endpoints
+func newGD2(config *conf.GConfig) *GD2 {
+	endpoints := newGD2Endpoints(config)
+	httpClient := &http.Client{
+		Transport: &debugRoundTripper{next: endpoints},
+		Timeout:   time.Duration(config.Timeout) * time.Second,
+	}
+	return &GD2{config: config, endpoints: endpoints, httpClient: httpClient}
+}
+
+// newGD2Endpoints creates the endpoints from the configuration, the
+// error if any is returned when creating the REST clients
+func newGD2Endpoints(config *conf.GConfig) *gd2Endpoints {
+	e := &gd2Endpoints{}
+	for _, endpoint := range config.Glusterd2Endpoints() {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			e.err = err
+			return e
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		e.urls = append(e.urls, u)
+	}
+	if len(e.urls) == 0 {
+		e.err = errors.New("no glusterd2 endpoints configured")
+		return e
+	}
+	e.up = make([]bool, len(e.urls))
+	for idx := range e.up {
+		e.up[idx] = true
+	}
+	e.failovers = make([]uint64, len(e.urls))
+	e.retries = config.Glusterd2Retries
+	e.backoff = time.Duration(config.Glusterd2RetryBackoff) * time.Millisecond
+
+	tlsConfig, err := restclient.NewTLSConfig(&restclient.TLSOptions{
+		CaCertFile:         config.Glusterd2Cacert,
+		InsecureSkipVerify: config.Glusterd2Insecure,
+	})
+	if err != nil {
+		e.err = err
+		return e
+	}
+	connectTimeout := time.Duration(config.Glusterd2ConnectTimeout) * time.Second
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.DisableCompression = true
+	transport.TLSClientConfig = tlsConfig
+	e.next = transport
+	return e
+}
+
+// baseURL returns the base URL used to build the requests,
+// requests are sent to the active endpoint irrespective of it
+func (e *gd2Endpoints) baseURL() string {
+	return e.urls[0].String()
+}
+
+// endpointRequest returns a copy of the request to be sent to the endpoint
+func (e *gd2Endpoints) endpointRequest(req *http.Request, idx int) (*http.Request, error) {
+	out := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errors.New("request body can't be resent")
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = body
+	}
+	u := e.urls[idx]
+	out.URL.Scheme = u.Scheme
+	out.URL.Host = u.Host
+	out.URL.Path = u.Path + strings.TrimPrefix(req.URL.Path, e.urls[0].Path)
+	out.Host = ""
+	// restclient closes the connection after each request,
+	// reuse the connections from the pool instead
+	out.Close = false
+	return out, nil
+}
+
+// shouldFailover returns true if the request failed because of the endpoint
+func shouldFailover(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// isDialError returns true if the connection to the endpoint
+// failed, before any part of the request is sent
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// canFailover returns true if the request can be resent to another
+// endpoint. Non-idempotent requests may have been acted upon even if
+// they failed, so they are failed over only if not sent at all.
+func canFailover(req *http.Request, resp *http.Response, err error) bool {
+	if isIdempotent(req) {
+		return shouldFailover(resp, err)
+	}
+	return err != nil && isDialError(err)
+}
+
+func (e *gd2Endpoints) send(req *http.Request, idx int) (*http.Response, error) {
+	out, err := e.endpointRequest(req, idx)
+	if err != nil {
+		return nil, err
+	}
+	return e.next.RoundTrip(out)
+}
+
+// healthCheck pings the endpoint
+func (e *gd2Endpoints) healthCheck(idx int) bool {
+	req, err := http.NewRequest(http.MethodGet, e.baseURL()+"/ping", nil)
+	if err != nil {
+		return false
+	}
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return e.send(r, idx)
+	}), Timeout: gd2HealthCheckTimeout}
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close() // #nosec
+	}
+	healthy := !shouldFailover(resp, err)
+	e.setUp(idx, healthy)
+	return healthy
+}
+
+func (e *gd2Endpoints) setUp(idx int, up bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.up[idx] = up
+}
+
+// setActive switches to the endpoint, if it is not the active one
+func (e *gd2Endpoints) setActive(idx int, reason string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.active == idx {
+		return
+	}
+	log.WithFields(log.Fields{
+		"from": e.urls[e.active].String(),
+		"to":   e.urls[idx].String(),
+	}).Warn(reason)
+	e.active = idx
+	e.failovers[idx]++
+}
+
+// failback switches to a healthy preferred endpoint, if the
+// preferred endpoints are not health checked recently
+func (e *gd2Endpoints) failback() {
+	e.lock.Lock()
+	active := e.active
+	if active == 0 || time.Since(e.lastFailback) < gd2FailbackInterval {
+		e.lock.Unlock()
+		return
+	}
+	e.lastFailback = time.Now()
+	e.lock.Unlock()
+
+	for idx := 0; idx < active; idx++ {
+		if e.healthCheck(idx) {
+			e.setActive(idx, "Failing back to the preferred glusterd2 endpoint")
+			return
+		}
+	}
+}
+
+// isIdempotent returns true if the request can be retried safely
+func isIdempotent(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+// RoundTrip implements 'http.RoundTripper'
+func (e *gd2Endpoints) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := e.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := e.roundTripOnce(req)
+		if !shouldFailover(resp, err) || !isIdempotent(req) || attempt >= e.retries {
+			return resp, err
+		}
+		if err == nil {
+			resp.Body.Close() // #nosec
+		}
+		log.WithError(err).WithFields(log.Fields{
+			"url":     req.URL.String(),
+			"attempt": attempt + 1,
+			"backoff": backoff,
+		}).Debug("glusterd2 request failed, retrying")
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// roundTripOnce sends the request to the active endpoint,
+// fails over to the other endpoints on failure
+func (e *gd2Endpoints) roundTripOnce(req *http.Request) (*http.Response, error) {
+	e.failback()
+	e.lock.Lock()
+	active := e.active
+	e.lock.Unlock()
+
+	resp, err := e.send(req, active)
+	e.setUp(active, !shouldFailover(resp, err))
+	if !canFailover(req, resp, err) {
+		return resp, err
+	}
+
+	// try the other endpoints in order, starting
+	// from the one next to the active endpoint
+	for n := 1; n < len(e.urls); n++ {
+		idx := (active + n) % len(e.urls)
+		if !e.healthCheck(idx) {
+			continue
+		}
+		e.setActive(idx, "Failing over to the next glusterd2 endpoint")
+		nextResp, nextErr := e.send(req, idx)
+		e.setUp(idx, !shouldFailover(nextResp, nextErr))
+		if !canFailover(req, nextResp, nextErr) {
+			if err == nil {
+				resp.Body.Close() // #nosec
+			}
+			return nextResp, nextErr
+		}
+		if nextErr == nil {
+			nextResp.Body.Close() // #nosec
+		}
+	}
+	return resp, err
+}
+
+// status returns the status of all the endpoints
+func (e *gd2Endpoints) status() []GD2EndpointStatus {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	statuses := make([]GD2EndpointStatus, len(e.urls))
+	for idx, u := range e.urls {
+		statuses[idx] = GD2EndpointStatus{
+			Endpoint:  u.String(),
+			Active:    idx == e.active,
+			Up:        e.up[idx],
+			Failovers: e.failovers[idx],
+		}
+	}
+	return statuses
+}
+
+// debugRoundTripper dumps the glusterd2 requests
+// and responses at debug log level
+type debugRoundTripper struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements 'http.RoundTripper'
+func (d *debugRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !log.IsLevelEnabled(log.DebugLevel) {
+		return d.next.RoundTrip(req)
+	}
+	if dump, err := httputil.DumpRequestOut(req, true); err == nil {
+		log.WithFields(log.Fields{
+			"method": req.Method,
+			"url":    req.URL.String(),
+		}).Debug("sending glusterd2 request\n", string(dump))
+	}
+	start := time.Now()
+	resp, err := d.next.RoundTrip(req)
+	if err != nil {
+		log.WithError(err).WithField("url", req.URL.String()).Debug("glusterd2 request failed")
+		return resp, err
+	}
+	if dump, err := httputil.DumpResponse(resp, true); err == nil {
+		log.WithFields(log.Fields{
+			"url":      req.URL.String(),
+			"status":   resp.Status,
+			"duration": time.Since(start).String(),
+		}).Debug("glusterd2 response received\n", string(dump))
+	}
+	return resp, err
+}
+
+// roundTripperFunc adapts a function to 'http.RoundTripper'
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// GD2EndpointsStatus returns the status of the glusterd2 endpoints,
+// nil if the gluster object is not using glusterd2
+func GD2EndpointsStatus(gi GInterface) []GD2EndpointStatus {
+	if gc, ok := gi.(*GCache); ok {
+		gi = gc.gd
+	}
+	if g, ok := gi.(*GD2); ok && g.endpoints != nil && g.endpoints.err == nil {
+		return g.endpoints.status()
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+//go:build ignore
+// +build ignore
+
+// gen_cache.go generates the GCache wrappers of all the methods of
+// 'GInterface' defined in types.go, run using `go generate`
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+const (
+	srcFile = "types.go"
+	outFile = "cache_wrappers.go"
+)
+
+// exprString returns the source of the type expression
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, expr); err != nil {
+		log.Fatal(err)
+	}
+	return buf.String()
+}
+
+func genWrapper(buf *bytes.Buffer, fset *token.FileSet, name string, fn *ast.FuncType) {
+	var params, args []string
+	for idx, param := range fn.Params.List {
+		pType := exprString(fset, param.Type)
+		names := param.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("arg%d", idx))}
+		}
+		for _, n := range names {
+			params = append(params, n.Name+" "+pType)
+			args = append(args, n.Name)
+		}
+	}
+	var results []string
+	for _, result := range fn.Results.List {
+		results = append(results, exprString(fset, result.Type))
+	}
+	if results[len(results)-1] != "error" || len(results) > 2 {
+		log.Fatalf("%s: only (T, error) and error results are supported", name)
+	}
+
+	keyArgs := append([]string{fmt.Sprintf("%q", name)}, args...)
+	callArgs := strings.Join(args, ", ")
+	fmt.Fprintf(buf, "// %s method wraps the GInterface.%s call\n", name, name)
+	if len(results) == 1 {
+		fmt.Fprintf(buf, "func (gc *GCache) %s(%s) error {\n", name, strings.Join(params, ", "))
+		fmt.Fprintf(buf, "\t_, err := cachedCall(gc, %q, cacheKey(%s), func() (struct{}, error) {\n",
+			name, strings.Join(keyArgs, ", "))
+		fmt.Fprintf(buf, "\t\treturn struct{}{}, gc.gd.%s(%s)\n", name, callArgs)
+		fmt.Fprintf(buf, "\t})\n\treturn err\n}\n\n")
+		return
+	}
+	fmt.Fprintf(buf, "func (gc *GCache) %s(%s) (%s, error) {\n", name, strings.Join(params, ", "), results[0])
+	fmt.Fprintf(buf, "\treturn cachedCall(gc, %q, cacheKey(%s), func() (%s, error) {\n",
+		name, strings.Join(keyArgs, ", "), results[0])
+	fmt.Fprintf(buf, "\t\treturn gc.gd.%s(%s)\n", name, callArgs)
+	fmt.Fprintf(buf, "\t})\n}\n\n")
+}
+
+func main() {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, srcFile, nil, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var iface *ast.InterfaceType
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok && ts.Name.Name == "GInterface" {
+			iface, _ = ts.Type.(*ast.InterfaceType)
+			return false
+		}
+		return true
+	})
+	if iface == nil {
+		log.Fatalf("GInterface not found in %s", srcFile)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen_cache.go; DO NOT EDIT.\n\n")
+	buf.WriteString("package glusterutils\n\n")
+	for _, method := range iface.Methods.List {
+		fn, ok := method.Type.(*ast.FuncType)
+		if !ok || len(method.Names) == 0 {
+			log.Fatalf("embedded interfaces are not supported in GInterface")
+		}
+		genWrapper(&buf, fset, method.Names[0].Name, fn)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(outFile, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
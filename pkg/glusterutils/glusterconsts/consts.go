@@ -22,6 +22,32 @@ const (
 	// BrickTypeArbiter represents arbiter brick type
 	BrickTypeArbiter = "Arbiter"
 
+	// DaemonNameSelfHeal represents the self-heal daemon in volume status
+	DaemonNameSelfHeal = "Self-heal Daemon"
+	// DaemonNameNFS represents the gluster NFS server in volume status
+	DaemonNameNFS = "NFS Server"
+	// DaemonNameQuota represents the quota daemon in volume status
+	DaemonNameQuota = "Quota Daemon"
+	// DaemonNameBitrot represents the bitrot daemon in volume status
+	DaemonNameBitrot = "Bitrot Daemon"
+	// DaemonNameScrubber represents the scrubber daemon in volume status
+	DaemonNameScrubber = "Scrubber Daemon"
+	// DaemonNameSnapshot represents the snapshot daemon in volume status
+	DaemonNameSnapshot = "Snapshot Daemon"
+
+	// DaemonSelfHeal represents self-heal daemon
+	DaemonSelfHeal = "shd"
+	// DaemonNFS represents gluster NFS server
+	DaemonNFS = "nfs"
+	// DaemonQuota represents quota daemon
+	DaemonQuota = "quotad"
+	// DaemonBitrot represents bitrot daemon
+	DaemonBitrot = "bitd"
+	// DaemonScrubber represents scrubber daemon
+	DaemonScrubber = "scrubber"
+	// DaemonSnapshot represents snapshot daemon
+	DaemonSnapshot = "snapd"
+
 	// MgmtGlusterd represents glusterd
 	MgmtGlusterd = "glusterd"
 	// MgmtGlusterd2 represents glusterd
@@ -47,6 +73,37 @@ const (
 	// LatencyMeasurementGD2 represents volume option for latency measurement
 	LatencyMeasurementGD2 = "debug/io-stats.latency-measurement"
 
+	// MinFreeDiskGD1 represents volume option for the minimum free disk space
+	MinFreeDiskGD1 = "cluster.min-free-disk"
+	// DefaultMinFreeDisk represents the default value of min-free-disk option
+	DefaultMinFreeDisk = "10%"
+
+	// BitrotGD1 represents volume option name for enabling bitrot detection
+	BitrotGD1 = "features.bitrot"
+
+	// OpVersionGD1 represents global option for the cluster operating version
+	OpVersionGD1 = "cluster.op-version"
+	// MaxOpVersionGD1 represents global option for the maximum operating
+	// version supported by the cluster
+	MaxOpVersionGD1 = "cluster.max-op-version"
+
 	// DefaultGlusterClusterID provides the default clusnter ID
 	DefaultGlusterClusterID = "default"
 )
+
+// PeerStatesGD1 represents the names of the glusterd peer states,
+// indexed by the state number
+var PeerStatesGD1 = []string{
+	"Establishing Connection",
+	"Probe Sent to Peer",
+	"Probe Received from Peer",
+	"Peer in Cluster",
+	"Accepted peer request",
+	"Sent and Received peer request",
+	"Peer Rejected",
+	"Peer detach in progress",
+	"Probe Received from peer",
+	"Connected to Peer",
+	"Peer is connected and Accepted",
+	"Invalid State",
+}
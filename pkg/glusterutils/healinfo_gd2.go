@@ -4,7 +4,7 @@ import "strings"
 
 // HealInfo gets heal info from glusterd2 using rest api
 func (g GD2) HealInfo(vol string) ([]HealEntry, error) {
-	client, err := initRESTClient(g.config)
+	client, err := g.initRESTClient()
 	if err != nil {
 		return nil, err
 	}
@@ -26,7 +26,7 @@ func (g GD2) HealInfo(vol string) ([]HealEntry, error) {
 
 // SplitBrainHealInfo gets heal info from glusterd2 using rest api
 func (g GD2) SplitBrainHealInfo(vol string) ([]HealEntry, error) {
-	client, err := initRESTClient(g.config)
+	client, err := g.initRESTClient()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,70 @@
+package glusterutils
+
+import (
+	"encoding/xml"
+)
+
+// VolumeMemStatus returns the memory status of each brick of the volume
+func (g *GD1) VolumeMemStatus(vol string) ([]BrickMemStatus, error) {
+	// Run gluster volume status {vol} mem
+	out, err := g.execGluster("volume", "status", vol, "mem")
+	if err != nil {
+		return nil, err
+	}
+	var volStatus gd1VolumeStatus
+	err = xml.Unmarshal(out, &volStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	var memStatus []BrickMemStatus
+	if len(volStatus.List) > 0 {
+		for _, process := range volStatus.List[0].NodeProcesses {
+			obj := BrickMemStatus{
+				Hostname: process.Hostname,
+				PeerID:   process.PeerID,
+				Path:     process.Path,
+				Volume:   vol,
+				MallInfo: MallInfo(process.MemStatus.MallInfo),
+			}
+			obj.MemPools = make([]MemPool, len(process.MemStatus.MemPools))
+			for idx, pool := range process.MemStatus.MemPools {
+				obj.MemPools[idx] = MemPool(pool)
+			}
+			memStatus = append(memStatus, obj)
+		}
+	}
+	return memStatus, nil
+}
+
+// VolumeInodeStatus returns the inode tables status of each brick of the volume
+func (g *GD1) VolumeInodeStatus(vol string) ([]BrickInodeStatus, error) {
+	// Run gluster volume status {vol} inode
+	out, err := g.execGluster("volume", "status", vol, "inode")
+	if err != nil {
+		return nil, err
+	}
+	var volStatus gd1VolumeStatus
+	err = xml.Unmarshal(out, &volStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	var inodeStatus []BrickInodeStatus
+	if len(volStatus.List) > 0 {
+		for _, process := range volStatus.List[0].NodeProcesses {
+			obj := BrickInodeStatus{
+				Hostname: process.Hostname,
+				PeerID:   process.PeerID,
+				Path:     process.Path,
+				Volume:   vol,
+			}
+			obj.InodeTables = make([]InodeTable, len(process.InodeStatus.Connections))
+			for idx, itable := range process.InodeStatus.Connections {
+				obj.InodeTables[idx] = InodeTable(itable)
+			}
+			inodeStatus = append(inodeStatus, obj)
+		}
+	}
+	return inodeStatus, nil
+}
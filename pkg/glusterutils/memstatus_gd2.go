@@ -0,0 +1,13 @@
+package glusterutils
+
+import "errors"
+
+// VolumeMemStatus returns the memory status of each brick of the volume
+func (g *GD2) VolumeMemStatus(vol string) ([]BrickMemStatus, error) {
+	return nil, errors.New("not implemented")
+}
+
+// VolumeInodeStatus returns the inode tables status of each brick of the volume
+func (g *GD2) VolumeInodeStatus(vol string) ([]BrickInodeStatus, error) {
+	return nil, errors.New("not implemented")
+}
@@ -0,0 +1,74 @@
+package glusterutils
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
+)
+
+// LocalOpVersion returns the operating version of the local glusterd
+// from glusterd.info
+func (g *GD1) LocalOpVersion() (int, error) {
+	infoFile := g.config.GlusterdWorkdir + "/glusterd.info"
+	f, err := os.Open(filepath.Clean(infoFile))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	// Sample content:
+	// UUID=0f7a1d0c-...
+	// operating-version=70200
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == "operating-version" {
+			return strconv.Atoi(strings.TrimSpace(parts[1]))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("unable to find operating version")
+}
+
+// ClusterOpVersion returns the current and the maximum supported
+// operating versions of the cluster
+func (g *GD1) ClusterOpVersion() (ClusterOpVersion, error) {
+	var opVersion ClusterOpVersion
+	// Run gluster volume get all all, which lists all the global options
+	out, err := g.execGluster("volume", "get", "all", "all")
+	if err != nil {
+		return opVersion, err
+	}
+	var opts gd1VolGetOpts
+	if err = xml.Unmarshal(out, &opts); err != nil {
+		return opVersion, err
+	}
+	found := 0
+	for _, opt := range opts.Opts {
+		var dst *int
+		switch opt.Option {
+		case glusterconsts.OpVersionGD1:
+			dst = &opVersion.OpVersion
+		case glusterconsts.MaxOpVersionGD1:
+			dst = &opVersion.MaxOpVersion
+		default:
+			continue
+		}
+		if *dst, err = strconv.Atoi(strings.TrimSpace(opt.Value)); err != nil {
+			return opVersion, err
+		}
+		found++
+	}
+	if found != 2 {
+		return opVersion, errors.New("unable to find cluster operating versions")
+	}
+	return opVersion, nil
+}
@@ -0,0 +1,14 @@
+package glusterutils
+
+import "errors"
+
+// LocalOpVersion returns the operating version of the local glusterd2
+func (g *GD2) LocalOpVersion() (int, error) {
+	return 0, errors.New("not implemented")
+}
+
+// ClusterOpVersion returns the current and the maximum supported
+// operating versions of the cluster
+func (g *GD2) ClusterOpVersion() (ClusterOpVersion, error) {
+	return ClusterOpVersion{}, errors.New("not implemented")
+}
@@ -2,6 +2,8 @@ package glusterutils
 
 import (
 	"encoding/xml"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
 )
 
 type peerGlusterd1 struct {
@@ -17,6 +19,15 @@ type peersGlusterd1 struct {
 	List    []peerGlusterd1 `xml:"peerStatus>peer"`
 }
 
+// peerStateName returns the name of the peer state, the name in the
+// output is used only if the state number is not known
+func peerStateName(state int, stateStr string) string {
+	if state >= 0 && state < len(glusterconsts.PeerStatesGD1) {
+		return glusterconsts.PeerStatesGD1[state]
+	}
+	return stateStr
+}
+
 // Peers returns the list of peers ( for GlusterD1 )
 func (g *GD1) Peers() ([]Peer, error) {
 	var gd1Peers peersGlusterd1
@@ -43,6 +54,7 @@ func (g *GD1) Peers() ([]Peer, error) {
 			PeerAddresses: peergd1.Hostname,
 			Online:        online,
 			Gd1State:      peergd1.State,
+			State:         peerStateName(peergd1.State, peergd1.StateStr),
 		}
 	}
 
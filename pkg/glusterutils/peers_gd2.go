@@ -11,7 +11,7 @@ var (
 // Peers returns the list of peers ( for GlusterD2 )
 func (g *GD2) Peers() ([]Peer, error) {
 	var peersgd2 []Peer
-	client, err := initRESTClient(g.config)
+	client, err := g.initRESTClient()
 	if err != nil {
 		return nil, err
 	}
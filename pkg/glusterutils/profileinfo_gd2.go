@@ -6,7 +6,7 @@ import (
 
 // VolumeProfileInfo returns profile info details for the volume
 func (g *GD2) VolumeProfileInfo(vol string) ([]ProfileInfo, error) {
-	client, err := initRESTClient(g.config)
+	client, err := g.initRESTClient()
 	if err != nil {
 		return nil, err
 	}
@@ -6,7 +6,7 @@ import (
 
 // Snapshots returns snaphosts list for the cluster
 func (g *GD2) Snapshots() ([]Snapshot, error) {
-	client, err := initRESTClient(g.config)
+	client, err := g.initRESTClient()
 	if err != nil {
 		return nil, err
 	}
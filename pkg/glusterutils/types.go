@@ -1,6 +1,10 @@
 package glusterutils
 
 import (
+	"context"
+	"net/http"
+	"time"
+
 	"github.com/gluster/gluster-prometheus/pkg/conf"
 )
 
@@ -10,6 +14,16 @@ type Peer struct {
 	PeerAddresses []string `json:"peer-addresses"`
 	Online        bool     `json:"online"`
 	Gd1State      int      // GD1 only
+	State         string   // GD1 only, name of the Gd1State
+}
+
+// Hostname returns the first address of the peer, or the
+// peer ID if the peer has no addresses
+func (p *Peer) Hostname() string {
+	if len(p.PeerAddresses) > 0 {
+		return p.PeerAddresses[0]
+	}
+	return p.ID
 }
 
 // Brick represents Gluster Brick
@@ -59,6 +73,18 @@ type VolumeStatus struct {
 	Nodes []BrickStatus
 }
 
+// DaemonStatus describes the status details of a volume auxiliary daemon
+// like self-heal daemon, quota daemon or NFS server
+type DaemonStatus struct {
+	Name     string // short daemon name (Ex: `shd`, `quotad`)
+	Hostname string
+	PeerID   string
+	Status   int
+	PID      int
+	Port     int
+	Volume   string
+}
+
 // Quota represents a volume quota
 type Quota struct {
 	Volume            string `json:"volume"`
@@ -103,6 +129,89 @@ type BrickStatus struct {
 	Gd1InodesTotal int64 // only valid with GD1, -1 with GD2
 }
 
+// BrickClient describes a single client connection to a brick
+type BrickClient struct {
+	Hostname     string // client address in host:port format
+	BytesRead    uint64
+	BytesWritten uint64
+	OpVersion    int
+}
+
+// BrickClients describes the clients connected to a volume brick
+type BrickClients struct {
+	Hostname    string
+	PeerID      string
+	Path        string
+	Volume      string
+	ClientCount int
+	Clients     []BrickClient
+}
+
+// MallInfo represents the glibc malloc statistics of a brick process
+type MallInfo struct {
+	Arena    uint64 // non-mmapped space allocated
+	Ordblks  uint64 // no of free chunks
+	Smblks   uint64 // no of free fastbin blocks
+	Hblks    uint64 // no of mmapped regions
+	Hblkhd   uint64 // space allocated in mmapped regions
+	Usmblks  uint64 // maximum total allocated space
+	Fsmblks  uint64 // space in freed fastbin blocks
+	Uordblks uint64 // total allocated space
+	Fordblks uint64 // total free space
+	Keepcost uint64 // top-most, releasable space
+}
+
+// MemPool represents a memory pool of a brick process
+type MemPool struct {
+	Name         string
+	HotCount     uint64
+	ColdCount    uint64
+	PaddedSizeOf uint64
+	AllocCount   uint64
+	MaxAlloc     uint64
+	PoolMisses   uint64
+	MaxStdAlloc  uint64
+}
+
+// BrickMemStatus describes the memory status of a volume brick
+type BrickMemStatus struct {
+	Hostname string
+	PeerID   string
+	Path     string
+	Volume   string
+	MallInfo MallInfo
+	MemPools []MemPool
+}
+
+// InodeTable represents the inode table of a brick connection
+type InodeTable struct {
+	ActiveSize uint64
+	LRUSize    uint64
+	PurgeSize  uint64
+}
+
+// BrickInodeStatus describes the inode tables status of a volume brick
+type BrickInodeStatus struct {
+	Hostname    string
+	PeerID      string
+	Path        string
+	Volume      string
+	InodeTables []InodeTable
+}
+
+// ScrubNodeStatus describes the bitrot scrub status of a volume on a node
+type ScrubNodeStatus struct {
+	Volume            string
+	Node              string
+	ScrubRunning      bool
+	ScrubbedFiles     uint64
+	SkippedFiles      uint64
+	LastScrubTime     time.Time // zero if no scrub is completed yet
+	LastScrubDuration time.Duration
+	ErrorCount        uint64
+	CorruptedObjects  []string
+}
+
 // GInterface should be implemented in GD1 and GD2 structs
 type GInterface interface {
 	Peers() ([]Peer, error)
@@ -117,6 +226,22 @@ type GInterface interface {
 	VolumeBrickStatus(vol string) ([]BrickStatus, error)
 	EnableVolumeProfiling(volinfo Volume) error
 	VolumeStatus() ([]VolumeStatus, error)
+	VolumeClients(vol string) ([]BrickClients, error)
+	VolumeMemStatus(vol string) ([]BrickMemStatus, error)
+	VolumeInodeStatus(vol string) ([]BrickInodeStatus, error)
+	VolumeDaemonStatus() ([]DaemonStatus, error)
+	BitrotScrubStatus(vol string) ([]ScrubNodeStatus, error)
+	LocalOpVersion() (int, error)
+	ClusterOpVersion() (ClusterOpVersion, error)
+}
+
+// ClusterOpVersion represents the operating versions of the cluster
+type ClusterOpVersion struct {
+	// OpVersion is the current operating version of the cluster
+	OpVersion int
+	// MaxOpVersion is the maximum operating version supported
+	// by all the peers of the cluster
+	MaxOpVersion int
 }
 
 // FopStat defines file ops related details
@@ -144,9 +269,17 @@ type ProfileInfo struct {
 // GD1 enables users to interact with gd1 version
 type GD1 struct {
 	config *conf.GConfig
+	// executor shared by all the collectors to run the gluster CLI
+	executor *cliExecutor
+	// running commands are killed once ctx is done
+	ctx context.Context
 }
 
 // GD2 is struct to interact with Glusterd2 using REST API
 type GD2 struct {
-	config *conf.GConfig
+	config    *conf.GConfig
+	endpoints *gd2Endpoints
+	// long-lived HTTP client sharing the connections
+	// across the requests, recreated on config reload
+	httpClient *http.Client
 }
@@ -53,14 +53,15 @@ func (g *GD1) VolumeInfo() ([]Volume, error) {
 			outvol.SubVolumes[sidx].DisperseRedundancyCount = vol.DisperseRedundancyCount
 			outvol.SubVolumes[sidx].Name = fmt.Sprintf("%s-%s-%d", vol.Name, strings.ToLower(subvolType), sidx)
 			for bidx := 0; bidx < subvolBricksCount; bidx++ {
+				volBrick := vol.Bricks[sidx*subvolBricksCount+bidx]
 				brickType := glusterconsts.BrickTypeDefault
-				if vol.Bricks[sidx+bidx].IsArbiter == 1 {
+				if volBrick.IsArbiter == 1 {
 					brickType = glusterconsts.BrickTypeArbiter
 				}
-				brickParts := strings.Split(vol.Bricks[sidx*subvolBricksCount+bidx].Name, ":")
+				brickParts := strings.Split(volBrick.Name, ":")
 				brick := Brick{
 					Host:       brickParts[0],
-					PeerID:     vol.Bricks[sidx*subvolBricksCount+bidx].PeerID,
+					PeerID:     volBrick.PeerID,
 					Type:       brickType,
 					Path:       brickParts[1],
 					VolumeID:   vol.ID,
@@ -13,7 +13,7 @@ var subvolTypeValueToName = map[api.SubvolType]string{
 
 // VolumeInfo returns gluster vol info (glusterd2)
 func (g *GD2) VolumeInfo() ([]Volume, error) {
-	client, err := initRESTClient(g.config)
+	client, err := g.initRESTClient()
 	if err != nil {
 		return nil, err
 	}
@@ -3,6 +3,8 @@ package glusterutils
 import (
 	"encoding/xml"
 	"strconv"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // VolumeStatus returns gluster vol status (glusterd)
@@ -24,8 +26,12 @@ func (g *GD1) VolumeStatus() ([]VolumeStatus, error) {
 		outvol := VolumeStatus{
 			Name: vol.Name,
 		}
-		outvol.Nodes = make([]BrickStatus, len(vol.Nodes))
-		for nidx, node := range vol.Nodes {
+		outvol.Nodes = make([]BrickStatus, 0, len(vol.Nodes))
+		for _, node := range vol.Nodes {
+			if _, isDaemon := node.daemonName(); isDaemon {
+				// auxiliary daemons are reported by VolumeDaemonStatus
+				continue
+			}
 			port64, err := strconv.ParseInt(node.Port, 10, 32)
 			if err != nil {
 				port64 = -1
@@ -44,10 +50,88 @@ func (g *GD1) VolumeStatus() ([]VolumeStatus, error) {
 				Volume:         vol.Name,
 				Path:           node.Path,
 			}
-			outvol.Nodes[nidx] = outnode
+			outvol.Nodes = append(outvol.Nodes, outnode)
 		}
 		outvols[vidx] = outvol
 	}
 
 	return outvols, nil
 }
+
+// VolumeDaemonStatus returns the status of auxiliary daemons like
+// self-heal daemon, quota daemon, NFS server etc (glusterd)
+func (g *GD1) VolumeDaemonStatus() ([]DaemonStatus, error) {
+	// Run Gluster volume status all, 'detail' lists only the bricks
+	out, err := g.execGluster("volume", "status", "all")
+	if err != nil {
+		return nil, err
+	}
+
+	var vols gd1VolumesDetail
+	err = xml.Unmarshal(out, &vols)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := g.peerHostnames(vols)
+	var daemons []DaemonStatus
+	for _, vol := range vols.List {
+		for _, node := range vol.Nodes {
+			name, isDaemon := node.daemonName()
+			if !isDaemon {
+				continue
+			}
+			port64, err := strconv.ParseInt(node.Port, 10, 32)
+			if err != nil {
+				port64 = -1
+			}
+			// for the daemons, 'hostname' field holds the daemon
+			// name and 'path' field holds the node hostname, which
+			// is 'localhost' for the daemons of the local node
+			hostname, ok := hosts[node.PeerID]
+			if !ok {
+				hostname = node.Path
+			}
+			daemons = append(daemons, DaemonStatus{
+				Name:     name,
+				Hostname: hostname,
+				PeerID:   node.PeerID,
+				Status:   node.Status,
+				PID:      node.PID,
+				Port:     int(port64),
+				Volume:   vol.Name,
+			})
+		}
+	}
+	return daemons, nil
+}
+
+// peerHostnames returns the hostnames of the peers keyed by peer ID.
+// Both the peers list and the volume status report the local node as
+// 'localhost', so the hostname of its bricks is used for it instead.
+func (g *GD1) peerHostnames(vols gd1VolumesDetail) map[string]string {
+	hosts := make(map[string]string)
+	peers, err := g.Peers()
+	if err != nil {
+		log.WithError(err).Debug("Unable to get the peers to resolve the daemon hostnames")
+	}
+	for _, peer := range peers {
+		for _, addr := range peer.PeerAddresses {
+			if addr != "localhost" {
+				hosts[peer.ID] = addr
+				break
+			}
+		}
+	}
+	for _, vol := range vols.List {
+		for _, node := range vol.Nodes {
+			if _, isDaemon := node.daemonName(); isDaemon || node.Hostname == "localhost" {
+				continue
+			}
+			if _, ok := hosts[node.PeerID]; !ok {
+				hosts[node.PeerID] = node.Hostname
+			}
+		}
+	}
+	return hosts
+}
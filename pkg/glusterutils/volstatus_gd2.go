@@ -1,8 +1,10 @@
 package glusterutils
 
+import "errors"
+
 // VolumeStatus returns gluster vol status (glusterd2)
 func (g *GD2) VolumeStatus() ([]VolumeStatus, error) {
-	client, err := initRESTClient(g.config)
+	client, err := g.initRESTClient()
 	if err != nil {
 		return nil, err
 	}
@@ -46,3 +48,9 @@ func (g *GD2) VolumeStatus() ([]VolumeStatus, error) {
 	}
 	return volumestatus, nil
 }
+
+// VolumeDaemonStatus returns the status of auxiliary daemons like
+// self-heal daemon, quota daemon, NFS server etc (glusterd2)
+func (g *GD2) VolumeDaemonStatus() ([]DaemonStatus, error) {
+	return nil, errors.New("not implemented")
+}
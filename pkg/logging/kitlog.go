@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"fmt"
+
+	kitlog "github.com/go-kit/log"
+	log "github.com/sirupsen/logrus"
+)
+
+// kitLogger adapts the logrus standard logger to the go-kit
+// logger interface used by the Prometheus libraries
+type kitLogger struct{}
+
+// NewKitLogger returns a go-kit logger, which logs using logrus
+func NewKitLogger() kitlog.Logger {
+	return &kitLogger{}
+}
+
+// Log logs the key value pairs, "level" and "msg" keys are
+// used as the log level and message of the log entry
+func (l *kitLogger) Log(keyvals ...interface{}) error {
+	fields := log.Fields{}
+	level := "info"
+	msg := ""
+	for idx := 0; idx+1 < len(keyvals); idx += 2 {
+		key := fmt.Sprint(keyvals[idx])
+		switch key {
+		case "level":
+			level = fmt.Sprint(keyvals[idx+1])
+		case "msg":
+			msg = fmt.Sprint(keyvals[idx+1])
+		default:
+			fields[key] = keyvals[idx+1]
+		}
+	}
+	entry := log.WithFields(fields)
+	switch level {
+	case "debug":
+		entry.Debug(msg)
+	case "warn":
+		entry.Warn(msg)
+	case "error":
+		entry.Error(msg)
+	default:
+		entry.Info(msg)
+	}
+	return nil
+}
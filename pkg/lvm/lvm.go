@@ -0,0 +1,323 @@
+package lvm
+
+import (
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	lvmCmd = "lvm"
+	// DefaultCacheTTL is the duration for which the LVM report is
+	// reused across the collectors, if the exporter does not set
+	// it based on the sync interval of the collectors
+	DefaultCacheTTL = 4 * time.Second
+)
+
+var (
+	reportFields = "lv_uuid,lv_name,lv_attr,lv_size,lv_path," +
+		"lv_kernel_major,lv_kernel_minor,data_percent," +
+		"lv_metadata_size,metadata_percent,pool_lv,pool_lv_uuid," +
+		"vg_name,vg_extent_count,vg_free_count,lv_count,pv_count"
+
+	// AutoextendConfigTTL is the duration for which the thin pool
+	// autoextend settings read from lvm.conf are cached
+	AutoextendConfigTTL = 5 * time.Minute
+
+	// CacheTTL is the duration for which the LVM report is cached,
+	// the report is regenerated for every call if set to zero
+	CacheTTL = DefaultCacheTTL
+
+	cache struct {
+		sync.Mutex
+		report    *Report
+		updatedAt time.Time
+	}
+
+	autoextendCache struct {
+		sync.Mutex
+		config    *AutoextendConfig
+		updatedAt time.Time
+	}
+)
+
+// AutoextendConfig represents the thin pool autoextend settings of lvm.conf
+type AutoextendConfig struct {
+	// Thin pool is extended when its usage exceeds this percentage,
+	// 100 disables the autoextension
+	ThresholdPercent float64
+	// Percentage of its size by which the thin pool is extended
+	ExtendPercent float64
+}
+
+// LV represents a logical volume
+type LV struct {
+	UUID   string
+	Name   string
+	VGName string
+	Path   string
+	Attr   string
+	// Size in bytes
+	Size float64
+	// device numbers of the active LV, -1 if LV is not active
+	KernelMajor     int
+	KernelMinor     int
+	DataPercent     float64
+	MetadataSize    float64
+	MetadataPercent float64
+	PoolLV          string
+	PoolLVUUID      string
+}
+
+// IsThinPool returns true if the LV is a thin pool
+func (lv *LV) IsThinPool() bool {
+	return len(lv.Attr) > 0 && lv.Attr[0] == 't'
+}
+
+// IsThinVolume returns true if the LV is a thinly provisioned volume
+func (lv *LV) IsThinVolume() bool {
+	return len(lv.Attr) > 0 && lv.Attr[0] == 'V'
+}
+
+// VG represents a volume group
+type VG struct {
+	Name        string
+	ExtentCount float64
+	FreeCount   float64
+	LVCount     int
+	PVCount     int
+}
+
+// Report represents the LVM inventory of the node
+type Report struct {
+	VGs []VG
+	LVs []LV
+	// time when the report is generated
+	GeneratedAt time.Time
+}
+
+type lvmReport struct {
+	Report []struct {
+		VG []map[string]string `json:"vg"`
+	} `json:"report"`
+}
+
+// VG returns the volume group with the given name
+func (r *Report) VG(name string) (VG, bool) {
+	for _, vg := range r.VGs {
+		if vg.Name == name {
+			return vg, true
+		}
+	}
+	return VG{}, false
+}
+
+// LVByDevice returns the active LV with the given device numbers
+func (r *Report) LVByDevice(major, minor int) (LV, bool) {
+	for _, lv := range r.LVs {
+		if lv.KernelMajor == major && lv.KernelMinor == minor {
+			return lv, true
+		}
+	}
+	return LV{}, false
+}
+
+// LVByPath returns the LV on which the given path is present
+func (r *Report) LVByPath(path string) (LV, bool, error) {
+	major, minor, err := DeviceNumbers(path)
+	if err != nil {
+		return LV{}, false, err
+	}
+	lv, ok := r.LVByDevice(major, minor)
+	return lv, ok, nil
+}
+
+// ThinPool returns the thin pool of the thinly provisioned LV
+func (r *Report) ThinPool(lv LV) (LV, bool) {
+	for _, pool := range r.LVs {
+		if pool.IsThinPool() && pool.VGName == lv.VGName && pool.Name == lv.PoolLV {
+			return pool, true
+		}
+	}
+	return LV{}, false
+}
+
+// DeviceNumbers returns the major and minor numbers of the
+// device on which the given path is present
+func DeviceNumbers(path string) (int, int, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	dev := uint64(stat.Dev) // #nosec
+	major := int(((dev >> 8) & 0xfff) | ((dev >> 32) & 0xfffff000))
+	minor := int((dev & 0xff) | ((dev >> 12) & 0xffffff00))
+	return major, minor, nil
+}
+
+func parseFloat(val string) (float64, error) {
+	if val == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(val, 64)
+}
+
+func parseInt(val string, defaultVal int) (int, error) {
+	if val == "" {
+		return defaultVal, nil
+	}
+	return strconv.Atoi(val)
+}
+
+func parseReport(out []byte) (*Report, error) {
+	var lvmOut lvmReport
+	if err := json.Unmarshal(out, &lvmOut); err != nil {
+		return nil, err
+	}
+	if len(lvmOut.Report) == 0 {
+		return nil, errors.New("empty lvm report")
+	}
+
+	report := &Report{GeneratedAt: time.Now()}
+	var err error
+	for _, row := range lvmOut.Report[0].VG {
+		if _, ok := report.VG(row["vg_name"]); !ok {
+			vg := VG{Name: row["vg_name"]}
+			if vg.ExtentCount, err = parseFloat(row["vg_extent_count"]); err != nil {
+				return nil, err
+			}
+			if vg.FreeCount, err = parseFloat(row["vg_free_count"]); err != nil {
+				return nil, err
+			}
+			if vg.LVCount, err = parseInt(row["lv_count"], 0); err != nil {
+				return nil, err
+			}
+			if vg.PVCount, err = parseInt(row["pv_count"], 0); err != nil {
+				return nil, err
+			}
+			report.VGs = append(report.VGs, vg)
+		}
+
+		// a row without LV details is reported for the VGs without LVs
+		if row["lv_uuid"] == "" {
+			continue
+		}
+		lv := LV{
+			UUID:       row["lv_uuid"],
+			Name:       row["lv_name"],
+			VGName:     row["vg_name"],
+			Path:       row["lv_path"],
+			Attr:       row["lv_attr"],
+			PoolLV:     row["pool_lv"],
+			PoolLVUUID: row["pool_lv_uuid"],
+		}
+		if lv.Size, err = parseFloat(row["lv_size"]); err != nil {
+			return nil, err
+		}
+		if lv.KernelMajor, err = parseInt(row["lv_kernel_major"], -1); err != nil {
+			return nil, err
+		}
+		if lv.KernelMinor, err = parseInt(row["lv_kernel_minor"], -1); err != nil {
+			return nil, err
+		}
+		if lv.DataPercent, err = parseFloat(row["data_percent"]); err != nil {
+			return nil, err
+		}
+		if lv.MetadataSize, err = parseFloat(row["lv_metadata_size"]); err != nil {
+			return nil, err
+		}
+		if lv.MetadataPercent, err = parseFloat(row["metadata_percent"]); err != nil {
+			return nil, err
+		}
+		report.LVs = append(report.LVs, lv)
+	}
+	return report, nil
+}
+
+func lvmCmdPath() string {
+	if fullcmd, err := exec.LookPath(lvmCmd); err == nil {
+		return fullcmd
+	}
+	return lvmCmd
+}
+
+func runReport() (*Report, error) {
+	out, err := exec.Command(lvmCmdPath(), "vgs", "--unquoted", "--reportformat=json", // #nosec
+		"--noheadings", "--nosuffix", "--units", "b", "-o", reportFields).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseReport(out)
+}
+
+// GetReport returns the LVM inventory of the node. The report is
+// cached for CacheTTL, so that all the collectors share a single
+// LVM scan instead of taking the LVM locks for each of them.
+func GetReport() (*Report, error) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	if cache.report != nil && time.Since(cache.updatedAt) < CacheTTL {
+		return cache.report, nil
+	}
+	report, err := runReport()
+	if err != nil {
+		return nil, err
+	}
+	cache.report = report
+	cache.updatedAt = time.Now()
+	return report, nil
+}
+
+func parseAutoextendConfig(out []byte) (*AutoextendConfig, error) {
+	// Sample output:
+	// thin_pool_autoextend_threshold=100
+	// thin_pool_autoextend_percent=20
+	config := &AutoextendConfig{}
+	for _, line := range strings.Split(string(out), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		val, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, err
+		}
+		switch parts[0] {
+		case "thin_pool_autoextend_threshold":
+			config.ThresholdPercent = val
+		case "thin_pool_autoextend_percent":
+			config.ExtendPercent = val
+		}
+	}
+	return config, nil
+}
+
+// ThinPoolAutoextend returns the thin pool autoextend settings, the
+// settings are read using `lvm lvmconfig` and cached for AutoextendConfigTTL
+func ThinPoolAutoextend() (*AutoextendConfig, error) {
+	autoextendCache.Lock()
+	defer autoextendCache.Unlock()
+
+	if autoextendCache.config != nil && time.Since(autoextendCache.updatedAt) < AutoextendConfigTTL {
+		return autoextendCache.config, nil
+	}
+	out, err := exec.Command(lvmCmdPath(), "lvmconfig", "--type", "full", // #nosec
+		"activation/thin_pool_autoextend_threshold",
+		"activation/thin_pool_autoextend_percent").Output()
+	if err != nil {
+		return nil, err
+	}
+	config, err := parseAutoextendConfig(out)
+	if err != nil {
+		return nil, err
+	}
+	autoextendCache.config = config
+	autoextendCache.updatedAt = time.Now()
+	return config, nil
+}
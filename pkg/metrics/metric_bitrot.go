@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"github.com/gluster/gluster-prometheus/pkg/conf"
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	bitrotScrubLabels = []MetricLabel{
+		clusterIDLabel,
+		{
+			Name: "volume",
+			Help: "Volume Name",
+		},
+		{
+			Name: "node",
+			Help: "Name of the node running the scrubber",
+		},
+	}
+
+	bitrotGaugeVecs = make(map[string]*ExportedGaugeVec)
+
+	glusterBitrotScrubRunning = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "bitrot_scrub_running",
+		Help:      "Bitrot scrub in progress (1-running, 0-idle)",
+		LongHelp:  "",
+		Labels:    bitrotScrubLabels,
+	}, &bitrotGaugeVecs)
+
+	glusterBitrotScrubbedFiles = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "bitrot_scrubbed_files",
+		Help:      "No of files scrubbed in the last scrub",
+		LongHelp:  "",
+		Labels:    bitrotScrubLabels,
+	}, &bitrotGaugeVecs)
+
+	glusterBitrotSkippedFiles = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "bitrot_skipped_files",
+		Help:      "No of files skipped in the last scrub",
+		LongHelp:  "",
+		Labels:    bitrotScrubLabels,
+	}, &bitrotGaugeVecs)
+
+	glusterBitrotLastScrubTime = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "bitrot_last_scrub_completed_timestamp_seconds",
+		Help:      "Unix time of the last completed scrub",
+		LongHelp:  "Unix time of the last completed scrub. Not exported until the scrubber completes its first scrub.",
+		Labels:    bitrotScrubLabels,
+	}, &bitrotGaugeVecs)
+
+	glusterBitrotLastScrubDuration = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "bitrot_last_scrub_duration_seconds",
+		Help:      "Duration of the last scrub in seconds",
+		LongHelp:  "",
+		Labels:    bitrotScrubLabels,
+	}, &bitrotGaugeVecs)
+
+	glusterBitrotCorruptedObjects = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "bitrot_corrupted_objects",
+		Help:      "No of corrupted objects detected by the scrubber",
+		LongHelp:  "",
+		Labels:    bitrotScrubLabels,
+	}, &bitrotGaugeVecs)
+)
+
+func getBitrotScrubLabels(vol string, node string) prometheus.Labels {
+	return prometheus.Labels{
+		"cluster_id": ClusterID,
+		"volume":     vol,
+		"node":       node,
+	}
+}
+
+func bitrotScrubStatus(gluster glusterutils.GInterface) error {
+	// Reset all vecs to not export stale information
+	for _, gaugeVec := range bitrotGaugeVecs {
+		gaugeVec.RemoveStaleMetrics()
+	}
+
+	isLeader, err := gluster.IsLeader()
+	if err != nil {
+		log.WithError(err).Debug("Unable to find if the current node is leader")
+		return err
+	}
+	if !isLeader {
+		return nil
+	}
+
+	volumes, err := gluster.VolumeInfo()
+	if err != nil {
+		return err
+	}
+	var glusterConfig *conf.GConfig
+	if glusterConfig, err = conf.GConfigFromInterface(gluster); err != nil {
+		return err
+	}
+
+	for _, volume := range volumes {
+		if volume.State != glusterconsts.VolumeStateStarted {
+			continue
+		}
+		// Volume options are available only with glusterd, with
+		// glusterd2 the scrub status call fails if bitrot is not enabled
+		if glusterConfig.GlusterMgmt != glusterconsts.MgmtGlusterd2 {
+			if value, exists := volume.Options[glusterconsts.BitrotGD1]; !exists || value != "on" {
+				continue
+			}
+		}
+		nodes, err := gluster.BitrotScrubStatus(volume.Name)
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"volume": volume.Name,
+			}).Debug("Error getting bitrot scrub status")
+			continue
+		}
+		for _, node := range nodes {
+			labels := getBitrotScrubLabels(volume.Name, node.Node)
+			running := 0
+			if node.ScrubRunning {
+				running = 1
+			}
+			bitrotGaugeVecs[glusterBitrotScrubRunning].Set(labels, float64(running))
+			bitrotGaugeVecs[glusterBitrotScrubbedFiles].Set(labels, float64(node.ScrubbedFiles))
+			bitrotGaugeVecs[glusterBitrotSkippedFiles].Set(labels, float64(node.SkippedFiles))
+			if !node.LastScrubTime.IsZero() {
+				bitrotGaugeVecs[glusterBitrotLastScrubTime].Set(labels, float64(node.LastScrubTime.Unix()))
+			}
+			bitrotGaugeVecs[glusterBitrotLastScrubDuration].Set(labels, node.LastScrubDuration.Seconds())
+			bitrotGaugeVecs[glusterBitrotCorruptedObjects].Set(labels, float64(node.ErrorCount))
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerMetric("gluster_bitrot", bitrotScrubStatus, resetVecs(bitrotGaugeVecs, nil))
+}
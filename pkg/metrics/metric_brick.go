@@ -1,17 +1,15 @@
 package metrics
 
 import (
-	"encoding/json"
-	"fmt"
 	"io/ioutil"
-	"os/exec"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
 	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
+	"github.com/gluster/gluster-prometheus/pkg/lvm"
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
@@ -108,12 +106,13 @@ var (
 			Name: "peer_id",
 			Help: "Peer ID",
 		},
-		{
-			Name: "pid",
-			Help: "Process ID of brick",
-		},
 	}
 
+	brickInfoLbls = append(append([]MetricLabel{}, brickStatusLbls...), MetricLabel{
+		Name: "pid",
+		Help: "Process ID of brick",
+	})
+
 	thinLvmLbls = []MetricLabel{
 		clusterIDLabel,
 		{
@@ -297,8 +296,44 @@ var (
 		LongHelp:  "",
 		Labels:    brickStatusLbls,
 	}, &brickStatusGaugeVecs)
+
+	glusterBrickInfo = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_info",
+		Help:      "Brick process information, value is always 1",
+		LongHelp:  "Brick process information with the process ID of the running brick as label. Exported only for the bricks which are up.",
+		Labels:    brickInfoLbls,
+	}, &brickStatusGaugeVecs)
+
+	glusterBrickStartTime = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_start_time_seconds",
+		Help:      "Start time of the brick process since unix epoch in seconds",
+		LongHelp:  "Start time of the brick process since unix epoch in seconds. Start time is read from `/proc` for the bricks local to the exporter, for the remote bricks it is the time when the exporter first observed the current process ID of the brick.",
+		Labels:    brickStatusLbls,
+	}, &brickStatusGaugeVecs)
+
+	brickStatusCounterVecs = make(map[string]*ExportedCounterVec)
+
+	glusterBrickRestarts = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_restarts_total",
+		Help:      "No of brick process restarts observed by the exporter",
+		LongHelp:  "No of brick process restarts observed by the exporter. A restart is detected when the process ID of a brick changes between two collection cycles, so restarts are counted only since the exporter started.",
+		Labels:    brickStatusLbls,
+	}, &brickStatusCounterVecs)
+
+	// brickProcs tracks the brick processes seen in the previous cycles,
+	// indexed by the volume name and the brick
+	brickProcs = make(map[string]brickProc)
 )
 
+// brickProc represents the last seen process of a brick
+type brickProc struct {
+	PID       int
+	StartTime float64
+}
+
 func getGlusterBrickLabels(brick glusterutils.Brick, subvol string) prometheus.Labels {
 	return prometheus.Labels{
 		"cluster_id": ClusterID,
@@ -345,7 +380,6 @@ func diskUsage(path string) (disk DiskStatus, err error) {
 
 // LVMStat represents LVM details
 type LVMStat struct {
-	Device          string
 	UUID            string
 	Name            string
 	DataPercent     float64
@@ -368,133 +402,20 @@ type ThinPoolStat struct {
 	ThinPoolDataUsed      float64
 	ThinPoolMetadataTotal float64
 	ThinPoolMetadataUsed  float64
+	// time when the usage is reported by LVM
+	SampledAt time.Time
 }
 
-// VGReport represents VG details
-type VGReport struct {
-	Report []VGs `json:"report"`
-}
-
-// VGs represents list VG Details
-type VGs struct {
-	Vgs []VGDetails `json:"vg"`
-}
-
-// VGDetails represents a single VG detail
-type VGDetails struct {
-	LVUUID          string `json:"lv_uuid"`
-	LVName          string `json:"lv_name"`
-	DataPercent     string `json:"data_percent"`
-	PoolLV          string `json:"pool_lv"`
-	LVAttr          string `json:"lv_attr"`
-	LVSize          string `json:"lv_size"`
-	LVPath          string `json:"lv_path"`
-	LVMetadataSize  string `json:"lv_metadata_size"`
-	MetadataPercent string `json:"metadata_percent"`
-	VGName          string `json:"vg_name"`
-	VGExtentTotal   string `json:"vg_extent_count"`
-	VGExtentFree    string `json:"vg_free_count"`
-}
-
-func getLVS() ([]LVMStat, []ThinPoolStat, error) {
-	cmd := "lvm vgs --unquoted --reportformat=json --noheading --nosuffix --units m -o lv_uuid,lv_name,data_percent,pool_lv,lv_attr,lv_size,lv_path,lv_metadata_size,metadata_percent,vg_name,vg_extent_count,vg_free_count"
-
-	out, err := exec.Command("sh", "-c", cmd).Output() // #nosec
-	lvmDet := []LVMStat{}
-	thinPool := []ThinPoolStat{}
-	var vgExtentFreeTemp float64
-	if err != nil {
-		log.WithError(err).Debug("Error getting lvm usage details")
-		return lvmDet, thinPool, err
-	}
-	var vgReport VGReport
-	if err1 := json.Unmarshal(out, &vgReport); err1 != nil {
-		log.WithError(err1).Debug("Error parsing lvm usage details")
-		return lvmDet, thinPool, err1
+func getThinPoolStat(pool lvm.LV, sampledAt time.Time) ThinPoolStat {
+	return ThinPoolStat{
+		ThinPoolName:          pool.Name,
+		ThinPoolVGName:        pool.VGName,
+		ThinPoolDataTotal:     pool.Size,
+		ThinPoolDataUsed:      (pool.Size * pool.DataPercent) / 100,
+		ThinPoolMetadataTotal: pool.MetadataSize,
+		ThinPoolMetadataUsed:  (pool.MetadataSize * pool.MetadataPercent) / 100,
+		SampledAt:             sampledAt,
 	}
-
-	for _, vg := range vgReport.Report[0].Vgs {
-		var obj LVMStat
-		obj.UUID = vg.LVUUID
-		obj.Name = vg.LVName
-		if vg.DataPercent == "" {
-			obj.DataPercent = 0.0
-		} else {
-			if obj.DataPercent, err = strconv.ParseFloat(vg.DataPercent, 64); err != nil {
-				log.WithError(err).Debug("Error parsing DataPercent value of lvm usage")
-				return lvmDet, thinPool, err
-			}
-		}
-		obj.PoolLV = vg.PoolLV
-		obj.Attr = vg.LVAttr
-		if vg.LVSize == "" {
-			obj.Size = 0.0
-		} else {
-			if obj.Size, err = strconv.ParseFloat(vg.LVSize, 64); err != nil {
-				log.WithError(err).Debug("Error parsing LVSize value of lvm usage")
-				return lvmDet, thinPool, err
-			}
-		}
-		obj.Path = vg.LVPath
-		if vg.LVMetadataSize == "" {
-			obj.MetadataSize = 0.0
-		} else {
-			if obj.MetadataSize, err = strconv.ParseFloat(vg.LVMetadataSize, 64); err != nil {
-				log.WithError(err).Debug("Error parsing LVMetadataSize value of lvm usage")
-				return lvmDet, thinPool, err
-			}
-		}
-		if vg.MetadataPercent == "" {
-			obj.MetadataPercent = 0.0
-		} else {
-			obj.MetadataPercent, err = strconv.ParseFloat(vg.MetadataPercent, 64)
-			if err != nil {
-				log.WithError(err).Debug("Error parsing MetadataPercent value of lvm usage")
-				return lvmDet, thinPool, err
-			}
-		}
-		if vg.VGExtentTotal == "" {
-			obj.VGExtentTotal = 0.0
-		} else {
-			obj.VGExtentTotal, err = strconv.ParseFloat(vg.VGExtentTotal, 64)
-			if err != nil {
-				log.WithError(err).Debug("Error parsing VGExtenTotal value of lvm usage")
-				return lvmDet, thinPool, err
-			}
-		}
-		if vg.VGExtentFree == "" {
-			vgExtentFreeTemp = 0.0
-		} else {
-			vgExtentFreeTemp, err = strconv.ParseFloat(vg.VGExtentFree, 64)
-			if err != nil {
-				log.WithError(err).Debug("Error parsing VGExtentAlloc value of lvm usage")
-				return lvmDet, thinPool, err
-			}
-		}
-		obj.VGExtentAlloc = obj.VGExtentTotal - vgExtentFreeTemp
-		obj.VGName = vg.VGName
-		if obj.Attr[0] == 't' {
-			obj.Device = fmt.Sprintf("%s/%s", obj.VGName, obj.Name)
-			var TPUsage ThinPoolStat
-			TPUsage.ThinPoolName = obj.Name
-			TPUsage.ThinPoolVGName = obj.VGName
-			TPUsage.ThinPoolDataTotal = obj.Size
-			TPUsage.ThinPoolDataUsed = (obj.Size * obj.DataPercent) / 100
-			TPUsage.ThinPoolMetadataTotal = obj.MetadataSize
-			TPUsage.ThinPoolMetadataUsed = (obj.MetadataSize * obj.MetadataPercent) / 100
-			thinPool = append(thinPool, TPUsage)
-		} else {
-			obj.Device, err = filepath.EvalSymlinks(obj.Path)
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"path": obj.Path,
-				}).Debug("Error evaluating realpath")
-				return lvmDet, thinPool, err
-			}
-		}
-		lvmDet = append(lvmDet, obj)
-	}
-	return lvmDet, thinPool, nil
 }
 
 // ProcMounts represents list of items from /proc/mounts
@@ -512,8 +433,10 @@ func parseProcMounts() ([]ProcMounts, error) {
 		return procMounts, err
 	}
 	for _, line := range strings.Split(string(b), "\n") {
-		if strings.HasPrefix(line, "/") {
-			tokens := strings.Fields(line)
+		tokens := strings.Fields(line)
+		// ZFS datasets are mounted with the dataset name
+		// as device, Ex: "tank/bricks /bricks zfs rw 0 0"
+		if strings.HasPrefix(line, "/") || (len(tokens) > 3 && tokens[2] == fsTypeZFS) {
 			procMounts = append(procMounts,
 				ProcMounts{Name: tokens[1], Device: tokens[0], FSType: tokens[2], MountOptions: tokens[3]})
 		}
@@ -548,44 +471,40 @@ func getGlusterThinPoolLabels(brick glusterutils.Brick, vol string, subvol strin
 }
 
 func lvmUsage(path string) (stats []LVMStat, thinPoolStats []ThinPoolStat, err error) {
-	mountPoints, err := parseProcMounts()
+	report, err := lvm.GetReport()
 	if err != nil {
 		return stats, thinPoolStats, err
 	}
-	var thinPoolNames []string
-	lvs, tpStats, err := getLVS()
-	if err != nil {
+	// Find the logical volume mounted as gluster brick
+	// using the device numbers of the brick path
+	lv, found, err := report.LVByPath(path)
+	if err != nil || !found {
 		return stats, thinPoolStats, err
 	}
-	for _, lv := range lvs {
-		for _, mount := range mountPoints {
-			dev, err := filepath.EvalSymlinks(mount.Device)
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"path": mount.Device,
-				}).Debug("Error evaluating realpath")
-				continue
-			}
-			// Check if the logical volume is mounted as a gluster brick
-			if lv.Device == dev && strings.HasPrefix(path, mount.Name) && strings.Contains(path, "/"+lv.VGName+"/"+lv.Name) {
-				// Check if the LV is a thinly provisioned volume and if yes then get the thin pool LV name
-				if lv.Attr[0] == 'V' {
-					tpName := lv.PoolLV
-					thinPoolNames = append(thinPoolNames, tpName)
-				}
-				stats = append(stats, lv)
-			}
-		}
+	stat := LVMStat{
+		UUID:            lv.UUID,
+		Name:            lv.Name,
+		DataPercent:     lv.DataPercent,
+		PoolLV:          lv.PoolLV,
+		Attr:            lv.Attr,
+		Size:            lv.Size,
+		Path:            lv.Path,
+		MetadataSize:    lv.MetadataSize,
+		MetadataPercent: lv.MetadataPercent,
+		VGName:          lv.VGName,
 	}
-	// Iterate and select only those thin pool LVs whose thinly provisioned volumes are mounted as gluster bricks
-	for _, tpName := range thinPoolNames {
-		for _, tpStat := range tpStats {
-			if tpName == tpStat.ThinPoolName {
-				thinPoolStats = append(thinPoolStats, tpStat)
-			}
-		}
+	if vg, ok := report.VG(lv.VGName); ok {
+		stat.VGExtentTotal = vg.ExtentCount
+		stat.VGExtentAlloc = vg.ExtentCount - vg.FreeCount
 	}
+	stats = append(stats, stat)
 
+	// Check if the LV is a thinly provisioned volume and if yes then get the thin pool LV
+	if lv.IsThinVolume() {
+		if pool, ok := report.ThinPool(lv); ok {
+			thinPoolStats = append(thinPoolStats, getThinPoolStat(pool, report.GeneratedAt))
+		}
+	}
 	return stats, thinPoolStats, nil
 }
 
@@ -594,6 +513,10 @@ func brickUtilization(gluster glusterutils.GInterface) error {
 	for _, gaugeVec := range brickGaugeVecs {
 		gaugeVec.RemoveStaleMetrics()
 	}
+	for _, counterVec := range brickCounterVecs {
+		counterVec.RemoveStaleMetrics()
+	}
+	pruneThinPoolHistory()
 
 	volumes, err := gluster.VolumeInfo()
 
@@ -608,6 +531,17 @@ func brickUtilization(gluster glusterutils.GInterface) error {
 		return err
 	}
 
+	// Disk I/O statistics are optional, log and continue
+	// exporting the other brick metrics on failure
+	mounts, err := parseProcMounts()
+	if err != nil {
+		log.WithError(err).Debug("Error reading mounts")
+	}
+	diskStats, err := parseDiskStats()
+	if err != nil {
+		log.WithError(err).Debug("Error reading disk statistics")
+	}
+
 	for _, volume := range volumes {
 		if volume.State != glusterconsts.VolumeStateStarted {
 			// Export brick metrics only if the Volume
@@ -648,33 +582,27 @@ func brickUtilization(gluster glusterutils.GInterface) error {
 							leastBrickTotal = usage.All
 						}
 					}
-					// Get lvm usage details
-					stats, thinStats, err := lvmUsage(brick.Path)
-					if err != nil {
+					mount, hasMount := getBrickMount(brick.Path, mounts)
+					// Get I/O statistics of the disks under the brick, only
+					// for the filesystems on block devices
+					if hasMount && strings.HasPrefix(mount.Device, "/") {
+						err = updateBrickDiskStats(brick, subvol.Name, mount.Device, diskStats)
+						if err != nil {
+							log.WithError(err).WithFields(log.Fields{
+								"volume":     volume.Name,
+								"brick_path": brick.Path,
+								"device":     mount.Device,
+							}).Debug("Error getting disk I/O statistics")
+						}
+					}
+					// Get the storage backend details like LVM, ZFS or Btrfs
+					backend := getBrickBackend(mount)
+					if err := backend.Export(brick, subvol.Name, mount); err != nil {
 						log.WithError(err).WithFields(log.Fields{
 							"volume":     volume.Name,
 							"brick_path": brick.Path,
-						}).Debug("Error getting lvm usage")
-						continue
-					}
-					// Add metrics
-					for _, stat := range stats {
-						var lvmLbls = getGlusterLVMLabels(brick, subvol.Name, stat)
-						// Convert to bytes
-						brickGaugeVecs[glusterBrickLVSize].Set(lvmLbls, stat.Size*1024*1024)
-						brickGaugeVecs[glusterBrickLVPercent].Set(lvmLbls, stat.DataPercent)
-						// Convert to bytes
-						brickGaugeVecs[glusterBrickLVMetadataSize].Set(lvmLbls, stat.MetadataSize*1024*1024)
-						brickGaugeVecs[glusterBrickLVMetadataPercent].Set(lvmLbls, stat.MetadataPercent)
-						brickGaugeVecs[glusterVGExtentTotal].Set(lvmLbls, stat.VGExtentTotal)
-						brickGaugeVecs[glusterVGExtentAlloc].Set(lvmLbls, stat.VGExtentAlloc)
-					}
-					for _, thinStat := range thinStats {
-						var thinLvmLbls = getGlusterThinPoolLabels(brick, volume.Name, subvol.Name, thinStat)
-						brickGaugeVecs[glusterThinPoolDataTotal].Set(thinLvmLbls, thinStat.ThinPoolDataTotal*1024*1024)
-						brickGaugeVecs[glusterThinPoolDataUsed].Set(thinLvmLbls, thinStat.ThinPoolDataUsed*1024*1024)
-						brickGaugeVecs[glusterThinPoolMetadataTotal].Set(thinLvmLbls, thinStat.ThinPoolMetadataTotal*1024*1024)
-						brickGaugeVecs[glusterThinPoolMetadataUsed].Set(thinLvmLbls, thinStat.ThinPoolMetadataUsed*1024*1024)
+							"backend":    backend.Name(),
+						}).Debug("Error getting brick backend details")
 					}
 				}
 			}
@@ -702,22 +630,101 @@ func brickUtilization(gluster glusterutils.GInterface) error {
 	return nil
 }
 
-func getBrickStatusLabels(vol string, host string, brickPath string, peerID string, pid int) prometheus.Labels {
+func getBrickStatusLabels(vol string, host string, brickPath string, peerID string) prometheus.Labels {
 	return prometheus.Labels{
 		"cluster_id": ClusterID,
 		"volume":     vol,
 		"hostname":   host,
 		"brick_path": brickPath,
 		"peer_id":    peerID,
-		"pid":        strconv.Itoa(pid),
 	}
 }
 
+func getBrickInfoLabels(vol string, host string, brickPath string, peerID string, pid int) prometheus.Labels {
+	labels := getBrickStatusLabels(vol, host, brickPath, peerID)
+	labels["pid"] = strconv.Itoa(pid)
+	return labels
+}
+
+// brickProcStartTime returns the start time of a local brick process
+// in seconds since unix epoch
+func brickProcStartTime(pid int) (float64, error) {
+	stat, err := readProcStat(strconv.Itoa(pid))
+	if err != nil {
+		return 0, err
+	}
+	boot, err := bootTime()
+	if err != nil {
+		return 0, err
+	}
+	return float64(procStartTime(boot, stat).UnixNano()) / 1e9, nil
+}
+
+// updateBrickProc compares the brick process with the one seen in the
+// previous cycle, and returns the updated process details and whether
+// the brick is restarted
+func updateBrickProc(entry glusterutils.BrickStatus, localPeerID string) (brickProc, bool) {
+	key := brickProcKey(entry)
+	prev, seen := brickProcs[key]
+	if entry.PID <= 0 || (seen && prev.PID == entry.PID) {
+		// brick is down or still running the same process,
+		// keep the last seen process details
+		return prev, false
+	}
+
+	// start time of the remote bricks can't be read, use the
+	// time when the new process is observed
+	proc := brickProc{
+		PID:       entry.PID,
+		StartTime: float64(time.Now().UnixNano()) / 1e9,
+	}
+	if entry.PeerID == localPeerID {
+		startTime, err := brickProcStartTime(entry.PID)
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"volume":     entry.Volume,
+				"brick_path": entry.Path,
+				"pid":        entry.PID,
+			}).Debug("Unable to read the start time of brick process")
+		} else {
+			proc.StartTime = startTime
+		}
+	}
+	brickProcs[key] = proc
+	// a brick coming up for the first time since the
+	// exporter started is not counted as a restart
+	return proc, seen
+}
+
+func brickProcKey(entry glusterutils.BrickStatus) string {
+	return entry.Volume + ":" + entry.Hostname + ":" + entry.Path
+}
+
+// pruneBrickProcs removes the bricks not seen in the current cycle, like
+// the bricks removed or replaced and the bricks of the deleted volumes.
+// Bricks of the volumes for which the status is not known are retained.
+func pruneBrickProcs(seen map[string]bool, unknownVolumes map[string]bool) {
+	for key := range brickProcs {
+		if seen[key] || unknownVolumes[strings.SplitN(key, ":", 2)[0]] {
+			continue
+		}
+		delete(brickProcs, key)
+	}
+}
+
+func resetBrickStatus() {
+	resetVecs(brickStatusGaugeVecs, brickStatusCounterVecs)()
+	brickProcs = make(map[string]brickProc)
+}
+
 func brickStatus(gluster glusterutils.GInterface) error {
 	// Reset all vecs to not export stale information
 	for _, gaugeVec := range brickStatusGaugeVecs {
 		gaugeVec.RemoveStaleMetrics()
 	}
+	for _, counterVec := range brickStatusCounterVecs {
+		counterVec.RemoveStaleMetrics()
+	}
 
 	isLeader, err := gluster.IsLeader()
 
@@ -727,14 +734,23 @@ func brickStatus(gluster glusterutils.GInterface) error {
 	}
 
 	if !isLeader {
+		// the bricks are tracked again once this node becomes the leader
+		brickProcs = make(map[string]brickProc)
 		return nil
 	}
 
+	localPeerID, err := gluster.LocalPeerID()
+	if err != nil {
+		return err
+	}
+
 	volumes, err := gluster.VolumeInfo()
 	if err != nil {
 		return err
 	}
 
+	seen := make(map[string]bool)
+	unknownVolumes := make(map[string]bool)
 	for _, volume := range volumes {
 		// If volume is down, the bricks should be marked down
 		var brickStatus []glusterutils.BrickStatus
@@ -758,19 +774,38 @@ func brickStatus(gluster glusterutils.GInterface) error {
 				log.WithError(err).WithFields(log.Fields{
 					"volume": volume.Name,
 				}).Debug("Error getting bricks status")
+				unknownVolumes[volume.Name] = true
 				continue
 			}
 		}
 		for _, entry := range brickStatus {
-			labels := getBrickStatusLabels(volume.Name, entry.Hostname, entry.Path, entry.PeerID, entry.PID)
+			labels := getBrickStatusLabels(volume.Name, entry.Hostname, entry.Path, entry.PeerID)
 			brickStatusGaugeVecs[glusterBrickUp].Set(labels, float64(entry.Status))
+
+			entry.Volume = volume.Name
+			seen[brickProcKey(entry)] = true
+			proc, restarted := updateBrickProc(entry, localPeerID)
+			if restarted {
+				brickStatusCounterVecs[glusterBrickRestarts].Inc(labels)
+			} else {
+				// export the series with zero restarts
+				brickStatusCounterVecs[glusterBrickRestarts].Add(labels, 0)
+			}
+			if proc.PID > 0 {
+				brickStatusGaugeVecs[glusterBrickStartTime].Set(labels, proc.StartTime)
+			}
+			if entry.Status == 1 && entry.PID > 0 {
+				brickStatusGaugeVecs[glusterBrickInfo].Set(
+					getBrickInfoLabels(volume.Name, entry.Hostname, entry.Path, entry.PeerID, entry.PID), 1)
+			}
 		}
 	}
+	pruneBrickProcs(seen, unknownVolumes)
 
 	return nil
 }
 
 func init() {
-	registerMetric("gluster_brick", brickUtilization)
-	registerMetric("gluster_brick_status", brickStatus)
+	registerMetric("gluster_brick", brickUtilization, resetVecs(brickGaugeVecs, brickCounterVecs))
+	registerMetric("gluster_brick_status", brickStatus, resetBrickStatus)
 }
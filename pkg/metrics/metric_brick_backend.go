@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"os/exec"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+	"github.com/gluster/gluster-prometheus/pkg/lvm"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BrickBackend represents the storage backend of a brick like LVM, ZFS
+// or Btrfs. Backend of a brick is chosen based on the filesystem type of
+// the brick mount.
+type BrickBackend interface {
+	// Name returns the name of the backend
+	Name() string
+	// Supports returns true if the brick mount is managed by the backend
+	Supports(mount ProcMounts) bool
+	// Export exports the backend metrics of the local brick
+	Export(brick glusterutils.Brick, subvol string, mount ProcMounts) error
+}
+
+var (
+	// brickBackends are checked in order, the default backend
+	// is used if none of them supports the brick mount
+	brickBackends []BrickBackend
+
+	defaultBrickBackend BrickBackend = &lvmBrickBackend{}
+)
+
+func registerBrickBackend(backend BrickBackend) {
+	brickBackends = append(brickBackends, backend)
+}
+
+func getBrickBackend(mount ProcMounts) BrickBackend {
+	for _, backend := range brickBackends {
+		if backend.Supports(mount) {
+			return backend
+		}
+	}
+	return defaultBrickBackend
+}
+
+// execCommand runs the command with the given arguments and returns the output
+func execCommand(name string, args ...string) ([]byte, error) {
+	cmd := name
+	if fullcmd, err := exec.LookPath(name); err == nil {
+		cmd = fullcmd
+	}
+	return exec.Command(cmd, args...).Output() // #nosec
+}
+
+// lvmBrickBackend exports the LV and thin pool details of
+// the bricks created on LVM
+type lvmBrickBackend struct{}
+
+func (b *lvmBrickBackend) Name() string {
+	return "lvm"
+}
+
+func (b *lvmBrickBackend) Supports(mount ProcMounts) bool {
+	return mount.FSType != fsTypeZFS && mount.FSType != fsTypeBtrfs
+}
+
+func (b *lvmBrickBackend) Export(brick glusterutils.Brick, subvol string, mount ProcMounts) error {
+	// Get lvm usage details
+	stats, thinStats, err := lvmUsage(brick.Path)
+	if err != nil {
+		return err
+	}
+	// Add metrics
+	for _, stat := range stats {
+		var lvmLbls = getGlusterLVMLabels(brick, subvol, stat)
+		brickGaugeVecs[glusterBrickLVSize].Set(lvmLbls, stat.Size)
+		brickGaugeVecs[glusterBrickLVPercent].Set(lvmLbls, stat.DataPercent)
+		brickGaugeVecs[glusterBrickLVMetadataSize].Set(lvmLbls, stat.MetadataSize)
+		brickGaugeVecs[glusterBrickLVMetadataPercent].Set(lvmLbls, stat.MetadataPercent)
+		brickGaugeVecs[glusterVGExtentTotal].Set(lvmLbls, stat.VGExtentTotal)
+		brickGaugeVecs[glusterVGExtentAlloc].Set(lvmLbls, stat.VGExtentAlloc)
+	}
+	for _, thinStat := range thinStats {
+		var thinLvmLbls = getGlusterThinPoolLabels(brick, brick.VolumeName, subvol, thinStat)
+		brickGaugeVecs[glusterThinPoolDataTotal].Set(thinLvmLbls, thinStat.ThinPoolDataTotal)
+		brickGaugeVecs[glusterThinPoolDataUsed].Set(thinLvmLbls, thinStat.ThinPoolDataUsed)
+		brickGaugeVecs[glusterThinPoolMetadataTotal].Set(thinLvmLbls, thinStat.ThinPoolMetadataTotal)
+		brickGaugeVecs[glusterThinPoolMetadataUsed].Set(thinLvmLbls, thinStat.ThinPoolMetadataUsed)
+		if dataSecs, metadataSecs, ok := thinPoolForecast(thinStat); ok {
+			brickGaugeVecs[glusterThinPoolDataSecondsUntilFull].Set(thinLvmLbls, dataSecs)
+			brickGaugeVecs[glusterThinPoolMetadataSecondsUntilFull].Set(thinLvmLbls, metadataSecs)
+		}
+		if autoextend, err := lvm.ThinPoolAutoextend(); err == nil {
+			brickGaugeVecs[glusterThinPoolAutoextendThreshold].Set(thinLvmLbls, autoextend.ThresholdPercent)
+			brickGaugeVecs[glusterThinPoolAutoextendPercent].Set(thinLvmLbls, autoextend.ExtendPercent)
+		} else {
+			log.WithError(err).Debug("Error getting thin pool autoextend settings")
+		}
+	}
+	return nil
+}
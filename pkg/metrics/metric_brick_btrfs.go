@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	sysFSBtrfsDir = "/sys/fs/btrfs"
+)
+
+var (
+	btrfsBlockGroupTypes = []string{"data", "metadata", "system"}
+
+	btrfsDeviceErrorLbls = append(append([]MetricLabel{}, brickLabels...),
+		MetricLabel{
+			Name: "device",
+			Help: "Device of the Btrfs filesystem",
+		},
+		MetricLabel{
+			Name: "type",
+			Help: "Type of the error(Ex: `write_io_errs`, `read_io_errs`, `corruption_errs`)",
+		},
+	)
+
+	btrfsAllocationLbls = append(append([]MetricLabel{}, brickLabels...),
+		MetricLabel{
+			Name: "fs_uuid",
+			Help: "UUID of the Btrfs filesystem",
+		},
+		MetricLabel{
+			Name: "block_group_type",
+			Help: "Type of the block group(`data`, `metadata` or `system`)",
+		},
+	)
+
+	glusterBrickBtrfsDeviceErrors = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_btrfs_device_errors_total",
+		Help:      "No of errors of the devices of the Btrfs filesystem of the brick",
+		LongHelp:  "No of errors of the devices of the Btrfs filesystem of the brick as reported by `btrfs device stats`.",
+		Labels:    btrfsDeviceErrorLbls,
+	}, &brickCounterVecs)
+
+	glusterBrickBtrfsAllocationTotal = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_btrfs_allocation_total_bytes",
+		Help:      "Bytes allocated to the block groups of the Btrfs filesystem of the brick",
+		LongHelp:  "",
+		Labels:    btrfsAllocationLbls,
+	}, &brickGaugeVecs)
+
+	glusterBrickBtrfsAllocationUsed = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_btrfs_allocation_used_bytes",
+		Help:      "Bytes used in the block groups of the Btrfs filesystem of the brick",
+		LongHelp:  "",
+		Labels:    btrfsAllocationLbls,
+	}, &brickGaugeVecs)
+)
+
+// BtrfsDeviceError represents an error counter of a Btrfs device
+type BtrfsDeviceError struct {
+	Device string
+	Type   string
+	Count  float64
+}
+
+// BtrfsAllocation represents the allocation of a Btrfs block group type
+type BtrfsAllocation struct {
+	BlockGroupType string
+	TotalBytes     float64
+	UsedBytes      float64
+}
+
+func parseBtrfsDeviceStats(out []byte) ([]BtrfsDeviceError, error) {
+	// Sample output of `btrfs device stats <mount>`:
+	// [/dev/vdb].write_io_errs    0
+	// [/dev/vdb].read_io_errs     0
+	var deviceErrors []BtrfsDeviceError
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], "[") {
+			continue
+		}
+		idx := strings.LastIndex(fields[0], "].")
+		if idx < 0 {
+			continue
+		}
+		count, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return deviceErrors, fmt.Errorf("invalid btrfs device stats: %s", line)
+		}
+		deviceErrors = append(deviceErrors, BtrfsDeviceError{
+			Device: fields[0][1:idx],
+			Type:   fields[0][idx+2:],
+			Count:  count,
+		})
+	}
+	return deviceErrors, nil
+}
+
+// getBtrfsUUID finds the UUID of the Btrfs filesystem from sysfs,
+// using the device of the filesystem
+func getBtrfsUUID(device string) (string, error) {
+	dev, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return "", err
+	}
+	devName := filepath.Base(dev)
+	fsDirs, err := ioutil.ReadDir(sysFSBtrfsDir)
+	if err != nil {
+		return "", err
+	}
+	for _, fsDir := range fsDirs {
+		devs, err := ioutil.ReadDir(filepath.Join(sysFSBtrfsDir, fsDir.Name(), "devices"))
+		if err != nil {
+			continue
+		}
+		for _, d := range devs {
+			if d.Name() == devName {
+				return fsDir.Name(), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("btrfs filesystem not found for the device %s", device)
+}
+
+func readSysFSValue(path string) (float64, error) {
+	out, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+}
+
+func getBtrfsAllocations(uuid string) ([]BtrfsAllocation, error) {
+	var allocations []BtrfsAllocation
+	for _, bgType := range btrfsBlockGroupTypes {
+		dir := filepath.Join(sysFSBtrfsDir, uuid, "allocation", bgType)
+		total, err := readSysFSValue(dir + "/total_bytes")
+		if err != nil {
+			return allocations, err
+		}
+		used, err := readSysFSValue(dir + "/bytes_used")
+		if err != nil {
+			return allocations, err
+		}
+		allocations = append(allocations, BtrfsAllocation{
+			BlockGroupType: bgType,
+			TotalBytes:     total,
+			UsedBytes:      used,
+		})
+	}
+	return allocations, nil
+}
+
+func getGlusterBtrfsAllocationLabels(brick glusterutils.Brick, subvol string, uuid string, bgType string) prometheus.Labels {
+	labels := getGlusterBrickLabels(brick, subvol)
+	labels["fs_uuid"] = uuid
+	labels["block_group_type"] = bgType
+	return labels
+}
+
+func getGlusterBtrfsDeviceErrorLabels(brick glusterutils.Brick, subvol string, devErr BtrfsDeviceError) prometheus.Labels {
+	labels := getGlusterBrickLabels(brick, subvol)
+	labels["device"] = devErr.Device
+	labels["type"] = devErr.Type
+	return labels
+}
+
+// btrfsBrickBackend exports the device errors and allocation
+// details of the bricks created on Btrfs subvolumes
+type btrfsBrickBackend struct{}
+
+func (b *btrfsBrickBackend) Name() string {
+	return "btrfs"
+}
+
+func (b *btrfsBrickBackend) Supports(mount ProcMounts) bool {
+	return mount.FSType == fsTypeBtrfs
+}
+
+func (b *btrfsBrickBackend) Export(brick glusterutils.Brick, subvol string, mount ProcMounts) error {
+	out, err := execCommand("btrfs", "device", "stats", mount.Name)
+	if err != nil {
+		return err
+	}
+	deviceErrors, err := parseBtrfsDeviceStats(out)
+	if err != nil {
+		return err
+	}
+	for _, devErr := range deviceErrors {
+		brickCounterVecs[glusterBrickBtrfsDeviceErrors].Set(
+			getGlusterBtrfsDeviceErrorLabels(brick, subvol, devErr), devErr.Count)
+	}
+
+	uuid, err := getBtrfsUUID(mount.Device)
+	if err != nil {
+		return err
+	}
+	allocations, err := getBtrfsAllocations(uuid)
+	if err != nil {
+		return err
+	}
+	for _, alloc := range allocations {
+		lbls := getGlusterBtrfsAllocationLabels(brick, subvol, uuid, alloc.BlockGroupType)
+		brickGaugeVecs[glusterBrickBtrfsAllocationTotal].Set(lbls, alloc.TotalBytes)
+		brickGaugeVecs[glusterBrickBtrfsAllocationUsed].Set(lbls, alloc.UsedBytes)
+	}
+	return nil
+}
+
+func init() {
+	registerBrickBackend(&btrfsBrickBackend{})
+}
@@ -0,0 +1,216 @@
+package metrics
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	sysClassBlockDir = "/sys/class/block"
+	procDiskStats    = "/proc/diskstats"
+	// size of a sector in /proc/diskstats, independent of the
+	// actual sector size of the device
+	diskSectorSize = 512
+)
+
+var (
+	brickDiskLbls = append(append([]MetricLabel{}, brickLabels...), MetricLabel{
+		Name: "device",
+		Help: "Name of the disk device under the brick(Ex: `sda`, `nvme0n1`)",
+	})
+
+	brickCounterVecs = make(map[string]*ExportedCounterVec)
+
+	glusterBrickDiskReads = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_disk_reads_completed_total",
+		Help:      "No of reads completed by the disks under the brick",
+		LongHelp:  "",
+		Labels:    brickDiskLbls,
+	}, &brickCounterVecs)
+
+	glusterBrickDiskWrites = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_disk_writes_completed_total",
+		Help:      "No of writes completed by the disks under the brick",
+		LongHelp:  "",
+		Labels:    brickDiskLbls,
+	}, &brickCounterVecs)
+
+	glusterBrickDiskReadBytes = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_disk_read_bytes_total",
+		Help:      "Bytes read by the disks under the brick",
+		LongHelp:  "",
+		Labels:    brickDiskLbls,
+	}, &brickCounterVecs)
+
+	glusterBrickDiskWrittenBytes = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_disk_written_bytes_total",
+		Help:      "Bytes written by the disks under the brick",
+		LongHelp:  "",
+		Labels:    brickDiskLbls,
+	}, &brickCounterVecs)
+
+	glusterBrickDiskIOTime = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_disk_io_time_seconds_total",
+		Help:      "Time spent doing I/Os by the disks under the brick in seconds",
+		LongHelp:  "Time spent doing I/Os by the disks under the brick in seconds. Rate of this metric is the utilization of the disk.",
+		Labels:    brickDiskLbls,
+	}, &brickCounterVecs)
+
+	glusterBrickDiskIOTimeWeighted = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_disk_io_time_weighted_seconds_total",
+		Help:      "Weighted time spent doing I/Os by the disks under the brick in seconds",
+		LongHelp:  "Weighted time spent doing I/Os by the disks under the brick in seconds. Rate of this metric is the average queue depth of the disk.",
+		Labels:    brickDiskLbls,
+	}, &brickCounterVecs)
+
+	glusterBrickDiskIONow = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_disk_io_now",
+		Help:      "No of I/Os currently in progress in the disks under the brick",
+		LongHelp:  "",
+		Labels:    brickDiskLbls,
+	}, &brickGaugeVecs)
+)
+
+// DiskStat represents the I/O statistics of a block device
+// from /proc/diskstats
+type DiskStat struct {
+	Name             string
+	ReadsCompleted   float64
+	SectorsRead      float64
+	WritesCompleted  float64
+	SectorsWritten   float64
+	IOsInProgress    float64
+	IOTimeMs         float64
+	WeightedIOTimeMs float64
+}
+
+func parseDiskStats() (map[string]DiskStat, error) {
+	stats := make(map[string]DiskStat)
+	f, err := os.Open(procDiskStats)
+	if err != nil {
+		return stats, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Sample line:
+		// 253 0 dm-0 8562 0 610178 3212 1207 0 17880 1164 0 3036 4376 ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 14 {
+			continue
+		}
+		var values [11]float64
+		valid := true
+		for idx := range values {
+			values[idx], err = strconv.ParseFloat(fields[idx+3], 64)
+			if err != nil {
+				valid = false
+				break
+			}
+		}
+		if !valid {
+			continue
+		}
+		stats[fields[2]] = DiskStat{
+			Name:             fields[2],
+			ReadsCompleted:   values[0],
+			SectorsRead:      values[2],
+			WritesCompleted:  values[4],
+			SectorsWritten:   values[6],
+			IOsInProgress:    values[8],
+			IOTimeMs:         values[9],
+			WeightedIOTimeMs: values[10],
+		}
+	}
+	return stats, scanner.Err()
+}
+
+// getDiskDevices resolves a block device through the stacked devices
+// like LVM, device-mapper or md to the underlying disks using
+// /sys/class/block/<dev>/slaves. Partitions are resolved to their disks.
+func getDiskDevices(device string) ([]string, error) {
+	dev, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return nil, err
+	}
+	return resolveDiskDevices(filepath.Base(dev), 0), nil
+}
+
+func resolveDiskDevices(name string, depth int) []string {
+	sysPath := sysClassBlockDir + "/" + name
+	slaves, err := ioutil.ReadDir(filepath.Clean(sysPath + "/slaves"))
+	// guard against the unexpected loops in device stacking
+	if err == nil && len(slaves) > 0 && depth < 8 {
+		var disks []string
+		for _, slave := range slaves {
+			for _, disk := range resolveDiskDevices(slave.Name(), depth+1) {
+				if !containsString(disks, disk) {
+					disks = append(disks, disk)
+				}
+			}
+		}
+		return disks
+	}
+
+	// If a partition, parent directory in sysfs is the disk
+	if _, err := os.Stat(filepath.Clean(sysPath + "/partition")); err == nil {
+		if realPath, err := filepath.EvalSymlinks(sysPath); err == nil {
+			return []string{filepath.Base(filepath.Dir(realPath))}
+		}
+	}
+	return []string{name}
+}
+
+func containsString(list []string, val string) bool {
+	for _, item := range list {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
+
+func getGlusterBrickDiskLabels(brick glusterutils.Brick, subvol string, device string) prometheus.Labels {
+	labels := getGlusterBrickLabels(brick, subvol)
+	labels["device"] = device
+	return labels
+}
+
+// updateBrickDiskStats exports the I/O statistics of the disks under the brick
+func updateBrickDiskStats(brick glusterutils.Brick, subvol string, device string, diskStats map[string]DiskStat) error {
+	disks, err := getDiskDevices(device)
+	if err != nil {
+		return err
+	}
+	for _, disk := range disks {
+		stat, ok := diskStats[disk]
+		if !ok {
+			continue
+		}
+		lbls := getGlusterBrickDiskLabels(brick, subvol, disk)
+		brickCounterVecs[glusterBrickDiskReads].Set(lbls, stat.ReadsCompleted)
+		brickCounterVecs[glusterBrickDiskWrites].Set(lbls, stat.WritesCompleted)
+		brickCounterVecs[glusterBrickDiskReadBytes].Set(lbls, stat.SectorsRead*diskSectorSize)
+		brickCounterVecs[glusterBrickDiskWrittenBytes].Set(lbls, stat.SectorsWritten*diskSectorSize)
+		brickCounterVecs[glusterBrickDiskIOTime].Set(lbls, stat.IOTimeMs/1000)
+		brickCounterVecs[glusterBrickDiskIOTimeWeighted].Set(lbls, stat.WeightedIOTimeMs/1000)
+		brickGaugeVecs[glusterBrickDiskIONow].Set(lbls, stat.IOsInProgress)
+	}
+	return nil
+}
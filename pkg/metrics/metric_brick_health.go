@@ -0,0 +1,263 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	sysFSExt4Dir = "/sys/fs/ext4"
+	fsTypeXFS    = "xfs"
+	fsTypeExt4   = "ext4"
+	fsTypeZFS    = "zfs"
+	fsTypeBtrfs  = "btrfs"
+)
+
+var (
+	// mount options of a brick filesystem exported by
+	// gluster_brick_fs_mount_option metric
+	brickMountOptions = []string{"inode64", "noatime"}
+
+	brickFSInfoLbls = append(append([]MetricLabel{}, brickLabels...),
+		MetricLabel{
+			Name: "fs_type",
+			Help: "Filesystem type of the brick(Ex: `xfs`, `ext4`)",
+		},
+		MetricLabel{
+			Name: "device",
+			Help: "Device of the brick filesystem",
+		},
+		MetricLabel{
+			Name: "mount_point",
+			Help: "Mount point of the brick filesystem",
+		},
+	)
+
+	brickMountOptionLbls = append(append([]MetricLabel{}, brickLabels...),
+		MetricLabel{
+			Name: "option",
+			Help: "Mount option(Ex: `inode64`, `noatime`)",
+		},
+	)
+
+	brickHealthGaugeVecs   = make(map[string]*ExportedGaugeVec)
+	brickHealthCounterVecs = make(map[string]*ExportedCounterVec)
+
+	glusterBrickOwnMount = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_own_mount",
+		Help:      "Brick path is on its own mount (1-yes, 0-no)",
+		LongHelp:  "Brick path is on its own mount (1-yes, 0-no). Zero means brick filesystem is not mounted and brick is writing to the root filesystem.",
+		Labels:    brickLabels,
+	}, &brickHealthGaugeVecs)
+
+	glusterBrickReadOnly = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_read_only",
+		Help:      "Brick filesystem is mounted read-only (1-yes, 0-no)",
+		LongHelp:  "Brick filesystem is mounted read-only (1-yes, 0-no). Filesystems like ext4 mounted with `errors=remount-ro` are remounted read-only by the kernel on errors, XFS shuts down the filesystem instead, which is reported by `gluster_brick_fs_accessible`.",
+		Labels:    brickLabels,
+	}, &brickHealthGaugeVecs)
+
+	glusterBrickFSAccessible = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_fs_accessible",
+		Help:      "Brick path is accessible (1-yes, 0-no)",
+		LongHelp:  "Brick path is accessible (1-yes, 0-no). Zero means stat of the brick path failed, for example with an I/O error after XFS shut down the brick filesystem on errors, which is not reflected in the mount options.",
+		Labels:    brickLabels,
+	}, &brickHealthGaugeVecs)
+
+	glusterBrickFSInfo = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_fs_info",
+		Help:      "Brick filesystem information, value is always 1",
+		LongHelp:  "",
+		Labels:    brickFSInfoLbls,
+	}, &brickHealthGaugeVecs)
+
+	glusterBrickMountOption = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_fs_mount_option",
+		Help:      "Mount option is enabled for the brick filesystem (1-yes, 0-no)",
+		LongHelp:  "Mount option is enabled for the brick filesystem (1-yes, 0-no). `inode64` is exported only for XFS, where it is the default unless mounted with `inode32`.",
+		Labels:    brickMountOptionLbls,
+	}, &brickHealthGaugeVecs)
+
+	glusterBrickFSErrors = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_fs_errors_total",
+		Help:      "No of errors reported by the kernel for the brick filesystem",
+		LongHelp:  "No of errors reported by the kernel for the brick filesystem since it is mounted, read from `/sys/fs/ext4/<device>/errors_count`. Available only for ext4, `/sys/fs/xfs/<device>/stats/stats` has no error counters. XFS shuts down the filesystem on errors instead, which is reported by `gluster_brick_fs_accessible`.",
+		Labels:    brickLabels,
+	}, &brickHealthCounterVecs)
+)
+
+// getBrickMount returns the mount on which the brick path is
+// present, which is the mount with the longest matching path
+func getBrickMount(path string, mounts []ProcMounts) (ProcMounts, bool) {
+	var brickMount ProcMounts
+	found := false
+	for _, mount := range mounts {
+		if path != mount.Name && mount.Name != "/" && !strings.HasPrefix(path, mount.Name+"/") {
+			continue
+		}
+		if !found || len(mount.Name) > len(brickMount.Name) {
+			brickMount = mount
+			found = true
+		}
+	}
+	return brickMount, found
+}
+
+// isOwnMount checks that the brick, with the given stat details,
+// is not on the root filesystem
+func isOwnMount(brickStat syscall.Stat_t) (bool, error) {
+	var rootStat syscall.Stat_t
+	if err := syscall.Stat("/", &rootStat); err != nil {
+		return false, err
+	}
+	return brickStat.Dev != rootStat.Dev, nil
+}
+
+func boolToFloat64(val bool) float64 {
+	if val {
+		return 1
+	}
+	return 0
+}
+
+func hasMountOption(options string, opt string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// ext4ErrorsCount reads the no of errors of an ext4 filesystem
+// from /sys/fs/ext4/<device>/errors_count
+func ext4ErrorsCount(device string) (float64, error) {
+	dev, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return 0, err
+	}
+	out, err := ioutil.ReadFile(filepath.Clean(sysFSExt4Dir + "/" + filepath.Base(dev) + "/errors_count"))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+}
+
+func brickHealth(gluster glusterutils.GInterface) error {
+	// Reset all vecs to not export stale information
+	for _, gaugeVec := range brickHealthGaugeVecs {
+		gaugeVec.RemoveStaleMetrics()
+	}
+	for _, counterVec := range brickHealthCounterVecs {
+		counterVec.RemoveStaleMetrics()
+	}
+
+	volumes, err := gluster.VolumeInfo()
+	if err != nil {
+		// Return without exporting metric in this cycle
+		return err
+	}
+
+	localPeerID, err := gluster.LocalPeerID()
+	if err != nil {
+		// Return without exporting metric in this cycle
+		return err
+	}
+
+	mounts, err := parseProcMounts()
+	if err != nil {
+		return err
+	}
+
+	for _, volume := range volumes {
+		for _, subvol := range volume.SubVolumes {
+			for _, brick := range subvol.Bricks {
+				if brick.PeerID != localPeerID {
+					continue
+				}
+				lbls := getGlusterBrickLabels(brick, subvol.Name)
+
+				// stat fails with an I/O error once the brick filesystem
+				// is shut down, the other metrics are read from the
+				// mounts and are exported for such a brick as well
+				var brickStat syscall.Stat_t
+				err := syscall.Stat(brick.Path, &brickStat)
+				brickHealthGaugeVecs[glusterBrickFSAccessible].Set(lbls, boolToFloat64(err == nil))
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"volume":     volume.Name,
+						"brick_path": brick.Path,
+					}).Debug("Brick path is not accessible")
+				} else if ownMount, err := isOwnMount(brickStat); err == nil {
+					brickHealthGaugeVecs[glusterBrickOwnMount].Set(lbls, boolToFloat64(ownMount))
+				} else {
+					log.WithError(err).WithFields(log.Fields{
+						"volume":     volume.Name,
+						"brick_path": brick.Path,
+					}).Debug("Error checking the brick mount")
+				}
+
+				mount, ok := getBrickMount(brick.Path, mounts)
+				if !ok {
+					continue
+				}
+				brickHealthGaugeVecs[glusterBrickReadOnly].Set(lbls,
+					boolToFloat64(hasMountOption(mount.MountOptions, "ro")))
+
+				infoLbls := getGlusterBrickLabels(brick, subvol.Name)
+				infoLbls["fs_type"] = mount.FSType
+				infoLbls["device"] = mount.Device
+				infoLbls["mount_point"] = mount.Name
+				brickHealthGaugeVecs[glusterBrickFSInfo].Set(infoLbls, 1)
+
+				for _, opt := range brickMountOptions {
+					enabled := hasMountOption(mount.MountOptions, opt)
+					if opt == "inode64" {
+						if mount.FSType != fsTypeXFS {
+							continue
+						}
+						enabled = !hasMountOption(mount.MountOptions, "inode32")
+					}
+					optLbls := getGlusterBrickLabels(brick, subvol.Name)
+					optLbls["option"] = opt
+					brickHealthGaugeVecs[glusterBrickMountOption].Set(optLbls, boolToFloat64(enabled))
+				}
+
+				// /sys/fs/xfs/<device>/stats/stats only has operation
+				// counters(extent_alloc, buf, log etc), XFS shuts down the
+				// filesystem on errors instead of counting them, which is
+				// reported by gluster_brick_fs_accessible
+				if mount.FSType == fsTypeExt4 {
+					errCount, err := ext4ErrorsCount(mount.Device)
+					if err != nil {
+						log.WithError(err).WithFields(log.Fields{
+							"volume":     volume.Name,
+							"brick_path": brick.Path,
+							"device":     mount.Device,
+						}).Debug("Error reading filesystem errors count")
+						continue
+					}
+					brickHealthCounterVecs[glusterBrickFSErrors].Set(lbls, errCount)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerMetric("gluster_brick_health", brickHealth, resetVecs(brickHealthGaugeVecs, brickHealthCounterVecs))
+}
@@ -0,0 +1,256 @@
+package metrics
+
+import (
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultBrickInternalsMaxEntries is the default no of entries
+	// scanned in each of the brick internal directories
+	DefaultBrickInternalsMaxEntries = 100000
+
+	xattrVolumeID = "trusted.glusterfs.volume-id"
+	xattrGFID     = "trusted.gfid"
+	// GFID of the brick root directory
+	rootGFID = "00000000000000000000000000000001"
+
+	readDirBatchSize = 1024
+)
+
+var (
+	// BrickInternalsVolumes is the list of volumes whose bricks are
+	// scanned by gluster_brick_internals collector, all volumes if empty
+	BrickInternalsVolumes []string
+	// BrickInternalsMaxEntries limits the no of entries scanned in each
+	// of the brick internal directories to limit the load on the bricks
+	BrickInternalsMaxEntries = DefaultBrickInternalsMaxEntries
+
+	brickInternalsGaugeVecs = make(map[string]*ExportedGaugeVec)
+
+	glusterBrickXattropEntries = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_xattrop_entries",
+		Help:      "No of entries in .glusterfs/indices/xattrop of the brick",
+		LongHelp:  "No of entries in `.glusterfs/indices/xattrop` of the brick, which are the files pending heal or with in-flight operations. Counting stops at `brick-internals-max-entries`.",
+		Labels:    brickLabels,
+	}, &brickInternalsGaugeVecs)
+
+	glusterBrickDirtyEntries = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_dirty_entries",
+		Help:      "No of entries in .glusterfs/indices/dirty of the brick",
+		LongHelp:  "No of entries in `.glusterfs/indices/dirty` of the brick. Counting stops at `brick-internals-max-entries`.",
+		Labels:    brickLabels,
+	}, &brickInternalsGaugeVecs)
+
+	glusterBrickLandfillBytes = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_landfill_bytes",
+		Help:      "Size of .glusterfs/landfill of the brick in bytes",
+		LongHelp:  "Size of `.glusterfs/landfill` of the brick in bytes, directories deleted and pending the purge. Scan stops at `brick-internals-max-entries` entries.",
+		Labels:    brickLabels,
+	}, &brickInternalsGaugeVecs)
+
+	glusterBrickUnlinkBytes = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_unlink_bytes",
+		Help:      "Size of .glusterfs/unlink of the brick in bytes",
+		LongHelp:  "Size of `.glusterfs/unlink` of the brick in bytes, files unlinked while still open. Scan stops at `brick-internals-max-entries` entries.",
+		Labels:    brickLabels,
+	}, &brickInternalsGaugeVecs)
+
+	glusterBrickVolumeIDMatch = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_volume_id_match",
+		Help:      "Volume ID xattr of the brick root matches the volume (1-yes, 0-no)",
+		LongHelp:  "`trusted.glusterfs.volume-id` xattr of the brick root matches the volume ID (1-yes, 0-no). Mismatch or missing xattr means the brick directory is not the one created for the volume.",
+		Labels:    brickLabels,
+	}, &brickInternalsGaugeVecs)
+
+	glusterBrickRootGFIDValid = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_root_gfid_valid",
+		Help:      "GFID xattr of the brick root is valid (1-yes, 0-no)",
+		LongHelp:  "",
+		Labels:    brickLabels,
+	}, &brickInternalsGaugeVecs)
+)
+
+// countDirEntries counts the entries of a directory excluding the
+// entries with the given prefix, counting stops at the limit
+func countDirEntries(dir string, excludePrefix string, limit int) (int, error) {
+	d, err := os.Open(filepath.Clean(dir))
+	if err != nil {
+		return 0, err
+	}
+	defer d.Close()
+
+	count := 0
+	for count < limit {
+		names, err := d.Readdirnames(readDirBatchSize)
+		for _, name := range names {
+			if excludePrefix != "" && strings.HasPrefix(name, excludePrefix) {
+				continue
+			}
+			count++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+	if count > limit {
+		count = limit
+	}
+	return count, nil
+}
+
+// dirSize returns the size of the files under the directory,
+// scan stops after visiting the given no of entries
+func dirSize(dir string, limit int) (float64, error) {
+	var size float64
+	visited := 0
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// files may be purged while walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		visited++
+		if visited > limit {
+			return io.EOF
+		}
+		if info.Mode().IsRegular() {
+			size += float64(info.Size())
+		}
+		return nil
+	})
+	if err == io.EOF {
+		err = nil
+	}
+	return size, err
+}
+
+func getXattr(path string, name string) ([]byte, error) {
+	buf := make([]byte, 64)
+	size, err := syscall.Getxattr(path, name, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
+
+// volumeIDMatches checks the volume ID xattr of the brick root
+func volumeIDMatches(brickPath string, volumeID string) bool {
+	val, err := getXattr(brickPath, xattrVolumeID)
+	if err != nil {
+		return false
+	}
+	return hex.EncodeToString(val) == strings.ToLower(strings.Replace(volumeID, "-", "", -1))
+}
+
+// rootGFIDValid checks the GFID xattr of the brick root
+func rootGFIDValid(brickPath string) bool {
+	val, err := getXattr(brickPath, xattrGFID)
+	if err != nil {
+		return false
+	}
+	return hex.EncodeToString(val) == rootGFID
+}
+
+func brickInternalsEnabled(volume string) bool {
+	if len(BrickInternalsVolumes) == 0 {
+		return true
+	}
+	return containsString(BrickInternalsVolumes, volume)
+}
+
+func brickInternals(gluster glusterutils.GInterface) error {
+	// Reset all vecs to not export stale information
+	for _, gaugeVec := range brickInternalsGaugeVecs {
+		gaugeVec.RemoveStaleMetrics()
+	}
+
+	volumes, err := gluster.VolumeInfo()
+	if err != nil {
+		// Return without exporting metric in this cycle
+		return err
+	}
+
+	localPeerID, err := gluster.LocalPeerID()
+	if err != nil {
+		// Return without exporting metric in this cycle
+		return err
+	}
+
+	for _, volume := range volumes {
+		if volume.State != glusterconsts.VolumeStateStarted || !brickInternalsEnabled(volume.Name) {
+			continue
+		}
+		for _, subvol := range volume.SubVolumes {
+			for _, brick := range subvol.Bricks {
+				if brick.PeerID != localPeerID {
+					continue
+				}
+				lbls := getGlusterBrickLabels(brick, subvol.Name)
+				logger := log.WithFields(log.Fields{
+					"volume":     volume.Name,
+					"brick_path": brick.Path,
+				})
+
+				brickInternalsGaugeVecs[glusterBrickVolumeIDMatch].Set(lbls,
+					boolToFloat64(volumeIDMatches(brick.Path, volume.ID)))
+				brickInternalsGaugeVecs[glusterBrickRootGFIDValid].Set(lbls,
+					boolToFloat64(rootGFIDValid(brick.Path)))
+
+				indicesDir := filepath.Join(brick.Path, ".glusterfs", "indices")
+				// Base files of the index directories are named as
+				// xattrop-<uuid> and dirty-<uuid>, which are not entries
+				count, err := countDirEntries(filepath.Join(indicesDir, "xattrop"), "xattrop-", BrickInternalsMaxEntries)
+				if err == nil {
+					brickInternalsGaugeVecs[glusterBrickXattropEntries].Set(lbls, float64(count))
+				} else {
+					logger.WithError(err).Debug("Error counting xattrop entries")
+				}
+				count, err = countDirEntries(filepath.Join(indicesDir, "dirty"), "dirty-", BrickInternalsMaxEntries)
+				if err == nil {
+					brickInternalsGaugeVecs[glusterBrickDirtyEntries].Set(lbls, float64(count))
+				} else {
+					logger.WithError(err).Debug("Error counting dirty entries")
+				}
+
+				size, err := dirSize(filepath.Join(brick.Path, ".glusterfs", "landfill"), BrickInternalsMaxEntries)
+				if err == nil {
+					brickInternalsGaugeVecs[glusterBrickLandfillBytes].Set(lbls, size)
+				} else {
+					logger.WithError(err).Debug("Error getting landfill size")
+				}
+				size, err = dirSize(filepath.Join(brick.Path, ".glusterfs", "unlink"), BrickInternalsMaxEntries)
+				if err == nil {
+					brickInternalsGaugeVecs[glusterBrickUnlinkBytes].Set(lbls, size)
+				} else {
+					logger.WithError(err).Debug("Error getting unlink size")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerOptionalMetric("gluster_brick_internals", brickInternals, resetVecs(brickInternalsGaugeVecs, nil))
+}
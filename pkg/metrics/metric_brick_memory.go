@@ -0,0 +1,239 @@
+package metrics
+
+import (
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	brickMemLabels = []MetricLabel{
+		clusterIDLabel,
+		{
+			Name: "volume",
+			Help: "Volume Name",
+		},
+		{
+			Name: "hostname",
+			Help: "Host name or IP",
+		},
+		{
+			Name: "brick_path",
+			Help: "Brick Path",
+		},
+	}
+
+	brickMemPoolLabels = []MetricLabel{
+		clusterIDLabel,
+		{
+			Name: "volume",
+			Help: "Volume Name",
+		},
+		{
+			Name: "hostname",
+			Help: "Host name or IP",
+		},
+		{
+			Name: "brick_path",
+			Help: "Brick Path",
+		},
+		{
+			Name: "pool",
+			Help: "Memory pool name",
+		},
+	}
+
+	brickMemGaugeVecs = make(map[string]*ExportedGaugeVec)
+
+	glusterBrickMallinfoArena = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_mallinfo_arena_bytes",
+		Help:      "Non-mmapped space allocated by the brick process in bytes",
+		LongHelp:  "",
+		Labels:    brickMemLabels,
+	}, &brickMemGaugeVecs)
+
+	glusterBrickMallinfoMmap = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_mallinfo_mmap_bytes",
+		Help:      "Space allocated in mmapped regions by the brick process in bytes",
+		LongHelp:  "",
+		Labels:    brickMemLabels,
+	}, &brickMemGaugeVecs)
+
+	glusterBrickMallinfoUsed = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_mallinfo_used_bytes",
+		Help:      "Total allocated space of the brick process in bytes",
+		LongHelp:  "",
+		Labels:    brickMemLabels,
+	}, &brickMemGaugeVecs)
+
+	glusterBrickMallinfoFree = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_mallinfo_free_bytes",
+		Help:      "Total free space of the brick process in bytes",
+		LongHelp:  "",
+		Labels:    brickMemLabels,
+	}, &brickMemGaugeVecs)
+
+	glusterBrickMallinfoKeepcost = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_mallinfo_keepcost_bytes",
+		Help:      "Top-most releasable space of the brick process in bytes",
+		LongHelp:  "",
+		Labels:    brickMemLabels,
+	}, &brickMemGaugeVecs)
+
+	glusterBrickMemPoolHotCount = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_mempool_hot_count",
+		Help:      "No of objects in use from the memory pool",
+		LongHelp:  "",
+		Labels:    brickMemPoolLabels,
+	}, &brickMemGaugeVecs)
+
+	glusterBrickMemPoolColdCount = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_mempool_cold_count",
+		Help:      "No of free objects available in the memory pool",
+		LongHelp:  "",
+		Labels:    brickMemPoolLabels,
+	}, &brickMemGaugeVecs)
+
+	glusterBrickMemPoolAllocCount = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_mempool_alloc_count",
+		Help:      "No of allocations served by the memory pool",
+		LongHelp:  "",
+		Labels:    brickMemPoolLabels,
+	}, &brickMemGaugeVecs)
+
+	glusterBrickMemPoolMisses = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_mempool_alloc_misses",
+		Help:      "No of allocations which missed the memory pool",
+		LongHelp:  "No of allocations which could not be served from the memory pool and fell back to the standard allocator. A steadily growing value for a pool hints at a memory leak in the brick process.",
+		Labels:    brickMemPoolLabels,
+	}, &brickMemGaugeVecs)
+
+	glusterBrickInodeTableActive = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_inode_table_active_size",
+		Help:      "No of active inodes in the brick inode tables",
+		LongHelp:  "",
+		Labels:    brickMemLabels,
+	}, &brickMemGaugeVecs)
+
+	glusterBrickInodeTableLRU = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_inode_table_lru_size",
+		Help:      "No of inodes in the LRU list of the brick inode tables",
+		LongHelp:  "",
+		Labels:    brickMemLabels,
+	}, &brickMemGaugeVecs)
+
+	glusterBrickInodeTablePurge = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_inode_table_purge_size",
+		Help:      "No of inodes in the purge list of the brick inode tables",
+		LongHelp:  "",
+		Labels:    brickMemLabels,
+	}, &brickMemGaugeVecs)
+)
+
+func getBrickMemLabels(vol string, host string, brickPath string) prometheus.Labels {
+	return prometheus.Labels{
+		"cluster_id": ClusterID,
+		"volume":     vol,
+		"hostname":   host,
+		"brick_path": brickPath,
+	}
+}
+
+func getBrickMemPoolLabels(vol string, host string, brickPath string, pool string) prometheus.Labels {
+	return prometheus.Labels{
+		"cluster_id": ClusterID,
+		"volume":     vol,
+		"hostname":   host,
+		"brick_path": brickPath,
+		"pool":       pool,
+	}
+}
+
+func brickMemory(gluster glusterutils.GInterface) error {
+	// Reset all vecs to not export stale information
+	for _, gaugeVec := range brickMemGaugeVecs {
+		gaugeVec.RemoveStaleMetrics()
+	}
+
+	isLeader, err := gluster.IsLeader()
+	if err != nil {
+		log.WithError(err).Debug("Unable to find if the current node is leader")
+		return err
+	}
+	if !isLeader {
+		return nil
+	}
+
+	volumes, err := gluster.VolumeInfo()
+	if err != nil {
+		return err
+	}
+
+	for _, volume := range volumes {
+		if volume.State != glusterconsts.VolumeStateStarted {
+			// Brick processes are running only for started volumes
+			continue
+		}
+		memStatus, err := gluster.VolumeMemStatus(volume.Name)
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"volume": volume.Name,
+			}).Debug("Error getting volume memory status")
+		}
+		for _, brick := range memStatus {
+			lbls := getBrickMemLabels(volume.Name, brick.Hostname, brick.Path)
+			brickMemGaugeVecs[glusterBrickMallinfoArena].Set(lbls, float64(brick.MallInfo.Arena))
+			brickMemGaugeVecs[glusterBrickMallinfoMmap].Set(lbls, float64(brick.MallInfo.Hblkhd))
+			brickMemGaugeVecs[glusterBrickMallinfoUsed].Set(lbls, float64(brick.MallInfo.Uordblks))
+			brickMemGaugeVecs[glusterBrickMallinfoFree].Set(lbls, float64(brick.MallInfo.Fordblks))
+			brickMemGaugeVecs[glusterBrickMallinfoKeepcost].Set(lbls, float64(brick.MallInfo.Keepcost))
+			for _, pool := range brick.MemPools {
+				poolLbls := getBrickMemPoolLabels(volume.Name, brick.Hostname, brick.Path, pool.Name)
+				brickMemGaugeVecs[glusterBrickMemPoolHotCount].Set(poolLbls, float64(pool.HotCount))
+				brickMemGaugeVecs[glusterBrickMemPoolColdCount].Set(poolLbls, float64(pool.ColdCount))
+				brickMemGaugeVecs[glusterBrickMemPoolAllocCount].Set(poolLbls, float64(pool.AllocCount))
+				brickMemGaugeVecs[glusterBrickMemPoolMisses].Set(poolLbls, float64(pool.PoolMisses))
+			}
+		}
+
+		inodeStatus, err := gluster.VolumeInodeStatus(volume.Name)
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"volume": volume.Name,
+			}).Debug("Error getting volume inode status")
+			continue
+		}
+		for _, brick := range inodeStatus {
+			var active, lru, purge uint64
+			// a brick has one inode table per connection
+			for _, itable := range brick.InodeTables {
+				active += itable.ActiveSize
+				lru += itable.LRUSize
+				purge += itable.PurgeSize
+			}
+			lbls := getBrickMemLabels(volume.Name, brick.Hostname, brick.Path)
+			brickMemGaugeVecs[glusterBrickInodeTableActive].Set(lbls, float64(active))
+			brickMemGaugeVecs[glusterBrickInodeTableLRU].Set(lbls, float64(lru))
+			brickMemGaugeVecs[glusterBrickInodeTablePurge].Set(lbls, float64(purge))
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerMetric("gluster_brick_memory", brickMemory, resetVecs(brickMemGaugeVecs, nil))
+}
@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	zfsPoolHealthStates = []string{
+		"ONLINE", "DEGRADED", "FAULTED", "OFFLINE", "UNAVAIL", "REMOVED",
+	}
+
+	zfsDatasetLbls = append(append([]MetricLabel{}, brickLabels...),
+		MetricLabel{
+			Name: "pool",
+			Help: "ZFS pool name",
+		},
+		MetricLabel{
+			Name: "dataset",
+			Help: "ZFS dataset name",
+		},
+	)
+
+	zfsPoolHealthLbls = append(append([]MetricLabel{}, zfsDatasetLbls...),
+		MetricLabel{
+			Name: "health",
+			Help: "ZFS pool health(Ex: `ONLINE`, `DEGRADED`, `FAULTED`)",
+		},
+	)
+
+	glusterBrickZFSPoolHealth = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_zfs_pool_health",
+		Help:      "ZFS pool health of the brick (1-current health, 0-otherwise)",
+		LongHelp:  "ZFS pool health of the brick. One metric is exported for each of the health states, value is 1 for the current health of the pool.",
+		Labels:    zfsPoolHealthLbls,
+	}, &brickGaugeVecs)
+
+	glusterBrickZFSUsed = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_zfs_used_bytes",
+		Help:      "Bytes used by the ZFS dataset of the brick and its descendents",
+		LongHelp:  "",
+		Labels:    zfsDatasetLbls,
+	}, &brickGaugeVecs)
+
+	glusterBrickZFSAvailable = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_zfs_available_bytes",
+		Help:      "Bytes available to the ZFS dataset of the brick",
+		LongHelp:  "",
+		Labels:    zfsDatasetLbls,
+	}, &brickGaugeVecs)
+
+	glusterBrickZFSReferenced = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_zfs_referenced_bytes",
+		Help:      "Bytes referenced by the ZFS dataset of the brick",
+		LongHelp:  "",
+		Labels:    zfsDatasetLbls,
+	}, &brickGaugeVecs)
+
+	glusterBrickZFSCompressRatio = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_zfs_compress_ratio",
+		Help:      "Compression ratio of the ZFS dataset of the brick",
+		LongHelp:  "",
+		Labels:    zfsDatasetLbls,
+	}, &brickGaugeVecs)
+
+	glusterBrickZFSQuota = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_zfs_quota_bytes",
+		Help:      "Quota of the ZFS dataset of the brick in bytes, 0 if no quota",
+		LongHelp:  "",
+		Labels:    zfsDatasetLbls,
+	}, &brickGaugeVecs)
+)
+
+// ZFSDatasetStat represents the details of a ZFS dataset
+type ZFSDatasetStat struct {
+	Pool          string
+	Dataset       string
+	PoolHealth    string
+	Used          float64
+	Available     float64
+	Referenced    float64
+	CompressRatio float64
+	Quota         float64
+}
+
+func parseZFSProperties(out []byte, stat *ZFSDatasetStat) error {
+	// Sample output of `zfs get -Hp -o property,value ...`:
+	// used	1277952
+	// compressratio	1.00x
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		val, err := strconv.ParseFloat(strings.TrimSuffix(fields[1], "x"), 64)
+		if err != nil {
+			return fmt.Errorf("invalid value of zfs property %s: %s", fields[0], fields[1])
+		}
+		switch fields[0] {
+		case "used":
+			stat.Used = val
+		case "available":
+			stat.Available = val
+		case "referenced":
+			stat.Referenced = val
+		case "compressratio":
+			stat.CompressRatio = val
+		case "quota":
+			stat.Quota = val
+		}
+	}
+	return nil
+}
+
+func getZFSDatasetStat(dataset string) (ZFSDatasetStat, error) {
+	stat := ZFSDatasetStat{
+		Pool:    strings.SplitN(dataset, "/", 2)[0],
+		Dataset: dataset,
+	}
+	out, err := execCommand("zfs", "get", "-Hp", "-o", "property,value",
+		"used,available,referenced,compressratio,quota", dataset)
+	if err != nil {
+		return stat, err
+	}
+	if err = parseZFSProperties(out, &stat); err != nil {
+		return stat, err
+	}
+	out, err = execCommand("zpool", "list", "-H", "-o", "health", stat.Pool)
+	if err != nil {
+		return stat, err
+	}
+	stat.PoolHealth = strings.TrimSpace(string(out))
+	return stat, nil
+}
+
+func getGlusterZFSLabels(brick glusterutils.Brick, subvol string, stat ZFSDatasetStat) prometheus.Labels {
+	labels := getGlusterBrickLabels(brick, subvol)
+	labels["pool"] = stat.Pool
+	labels["dataset"] = stat.Dataset
+	return labels
+}
+
+// zfsBrickBackend exports the pool and dataset details
+// of the bricks created on ZFS datasets
+type zfsBrickBackend struct{}
+
+func (b *zfsBrickBackend) Name() string {
+	return "zfs"
+}
+
+func (b *zfsBrickBackend) Supports(mount ProcMounts) bool {
+	return mount.FSType == fsTypeZFS
+}
+
+func (b *zfsBrickBackend) Export(brick glusterutils.Brick, subvol string, mount ProcMounts) error {
+	stat, err := getZFSDatasetStat(mount.Device)
+	if err != nil {
+		return err
+	}
+	lbls := getGlusterZFSLabels(brick, subvol, stat)
+	brickGaugeVecs[glusterBrickZFSUsed].Set(lbls, stat.Used)
+	brickGaugeVecs[glusterBrickZFSAvailable].Set(lbls, stat.Available)
+	brickGaugeVecs[glusterBrickZFSReferenced].Set(lbls, stat.Referenced)
+	brickGaugeVecs[glusterBrickZFSCompressRatio].Set(lbls, stat.CompressRatio)
+	brickGaugeVecs[glusterBrickZFSQuota].Set(lbls, stat.Quota)
+	for _, health := range zfsPoolHealthStates {
+		healthLbls := getGlusterZFSLabels(brick, subvol, stat)
+		healthLbls["health"] = health
+		brickGaugeVecs[glusterBrickZFSPoolHealth].Set(healthLbls, boolToFloat64(stat.PoolHealth == health))
+	}
+	return nil
+}
+
+func init() {
+	registerBrickBackend(&zfsBrickBackend{})
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	daemonStatusLbls = []MetricLabel{
+		clusterIDLabel,
+		{
+			Name: "volume",
+			Help: "Volume Name",
+		},
+		{
+			Name: "host",
+			Help: "Host name or IP",
+		},
+		{
+			Name: "daemon",
+			Help: "Name of the daemon(Ex: `shd`, `nfs`, `quotad`, `bitd`, `scrubber`, `snapd`)",
+		},
+	}
+
+	daemonStatusGaugeVecs = make(map[string]*ExportedGaugeVec)
+
+	glusterDaemonUp = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "daemon_up",
+		Help:      "Daemon up (1-up, 0-down)",
+		LongHelp:  "Status of the volume auxiliary daemons like self-heal daemon, quota daemon, bitrot daemon, scrubber, snapshot daemon and NFS server, as listed in `gluster volume status` on each host.",
+		Labels:    daemonStatusLbls,
+	}, &daemonStatusGaugeVecs)
+
+	glusterDaemonPid = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "daemon_pid",
+		Help:      "Process ID of the daemon",
+		LongHelp:  "",
+		Labels:    daemonStatusLbls,
+	}, &daemonStatusGaugeVecs)
+)
+
+func getDaemonStatusLabels(vol string, host string, daemon string) prometheus.Labels {
+	return prometheus.Labels{
+		"cluster_id": ClusterID,
+		"volume":     vol,
+		"host":       host,
+		"daemon":     daemon,
+	}
+}
+
+func daemonStatus(gluster glusterutils.GInterface) error {
+	// Reset all vecs to not export stale information
+	for _, gaugeVec := range daemonStatusGaugeVecs {
+		gaugeVec.RemoveStaleMetrics()
+	}
+
+	isLeader, err := gluster.IsLeader()
+	if err != nil {
+		log.WithError(err).Debug("Unable to find if the current node is leader")
+		return err
+	}
+	if !isLeader {
+		return nil
+	}
+
+	daemons, err := gluster.VolumeDaemonStatus()
+	if err != nil {
+		return err
+	}
+
+	for _, daemon := range daemons {
+		labels := getDaemonStatusLabels(daemon.Volume, daemon.Hostname, daemon.Name)
+		daemonStatusGaugeVecs[glusterDaemonUp].Set(labels, float64(daemon.Status))
+		daemonStatusGaugeVecs[glusterDaemonPid].Set(labels, float64(daemon.PID))
+	}
+	return nil
+}
+
+func init() {
+	registerMetric("gluster_daemon_status", daemonStatus, resetVecs(daemonStatusGaugeVecs, nil))
+}
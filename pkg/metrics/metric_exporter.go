@@ -0,0 +1,261 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	exporterLabels = []MetricLabel{
+		{
+			Name: "instance",
+			Help: "Hostname of the gluster-prometheus instance providing this metric",
+		},
+	}
+
+	reloadLabels = append(append([]MetricLabel{}, exporterLabels...), MetricLabel{
+		Name: "result",
+		Help: "Result of the configuration reload(`success` or `failure`)",
+	})
+
+	cliLabels = append(append([]MetricLabel{}, exporterLabels...), MetricLabel{
+		Name: "command",
+		Help: "Gluster CLI subcommand (Ex: `volume status`)",
+	})
+
+	cacheLabels = append(append([]MetricLabel{}, exporterLabels...), MetricLabel{
+		Name: "function",
+		Help: "Name of the cached function",
+	})
+
+	gd2EndpointLabels = append(append([]MetricLabel{}, exporterLabels...), MetricLabel{
+		Name: "endpoint",
+		Help: "Glusterd2 REST endpoint",
+	})
+
+	exporterGaugeVecs   = make(map[string]*ExportedGaugeVec)
+	exporterCounterVecs = make(map[string]*ExportedCounterVec)
+
+	glusterExporterReloadSuccessful = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_config_last_reload_successful",
+		Help:      "Last configuration reload of the exporter is successful (1-success, 0-failure)",
+		LongHelp:  "",
+		Labels:    exporterLabels,
+	}, &exporterGaugeVecs)
+
+	glusterExporterReloadSuccessTime = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_config_last_reload_success_timestamp_seconds",
+		Help:      "Time of the last successful configuration reload since unix epoch in seconds",
+		LongHelp:  "Time of the last successful configuration load or reload since unix epoch in seconds.",
+		Labels:    exporterLabels,
+	}, &exporterGaugeVecs)
+
+	glusterExporterReloads = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_config_reloads_total",
+		Help:      "No of configuration reloads of the exporter",
+		LongHelp:  "No of configuration reloads of the exporter triggered by SIGHUP or the reload endpoint.",
+		Labels:    reloadLabels,
+	}, &exporterCounterVecs)
+
+	// metrics exported by the gluster_exporter collector, maintained
+	// separately from the reload metrics which are not updated periodically
+	exporterStatusGaugeVecs   = make(map[string]*ExportedGaugeVec)
+	exporterStatusCounterVecs = make(map[string]*ExportedCounterVec)
+
+	glusterExporterGD2EndpointActive = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_gd2_endpoint_active",
+		Help:      "Glusterd2 endpoint is the one in use (1-active, 0-standby)",
+		LongHelp:  "Glusterd2 endpoint is the one in use (1-active, 0-standby). Requests are failed over to the next healthy endpoint in the configured order on connection errors and 5xx responses.",
+		Labels:    gd2EndpointLabels,
+	}, &exporterStatusGaugeVecs)
+
+	glusterExporterGD2EndpointUp = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_gd2_endpoint_up",
+		Help:      "Result of the last request or health check of the Glusterd2 endpoint (1-up, 0-down)",
+		LongHelp:  "",
+		Labels:    gd2EndpointLabels,
+	}, &exporterStatusGaugeVecs)
+
+	glusterExporterGD2EndpointFailovers = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_gd2_endpoint_failovers_total",
+		Help:      "No of times the exporter switched over to the Glusterd2 endpoint",
+		LongHelp:  "No of times the exporter switched over to the Glusterd2 endpoint, either failing over to it or failing back to it.",
+		Labels:    gd2EndpointLabels,
+	}, &exporterStatusCounterVecs)
+
+	glusterExporterCacheHits = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_cache_hits_total",
+		Help:      "No of calls served from the cache",
+		LongHelp:  "No of calls of the functions listed in `cache-enabled-funcs` served from the cache.",
+		Labels:    cacheLabels,
+	}, &exporterStatusCounterVecs)
+
+	glusterExporterCacheMisses = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_cache_misses_total",
+		Help:      "No of calls not found in the cache, and made to glusterd",
+		LongHelp:  "",
+		Labels:    cacheLabels,
+	}, &exporterStatusCounterVecs)
+
+	glusterExporterCacheShared = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_cache_shared_total",
+		Help:      "No of calls which shared the result of an identical call in progress",
+		LongHelp:  "",
+		Labels:    cacheLabels,
+	}, &exporterStatusCounterVecs)
+
+	glusterExporterCacheNegativeHits = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_cache_negative_hits_total",
+		Help:      "No of calls served with a cached error",
+		LongHelp:  "No of calls served with the error of the previous call, cached for `cache-negative-ttl-in-sec`.",
+		Labels:    cacheLabels,
+	}, &exporterStatusCounterVecs)
+
+	glusterExporterCacheStaleServed = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_cache_stale_served_total",
+		Help:      "No of calls served with a stale value as the call failed",
+		LongHelp:  "No of calls served with the last successful result as the call failed, only if `cache-serve-stale` is enabled.",
+		Labels:    cacheLabels,
+	}, &exporterStatusCounterVecs)
+
+	glusterExporterCacheLastStaleAge = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_cache_last_stale_age_seconds",
+		Help:      "Age of the last stale value served from the cache",
+		LongHelp:  "",
+		Labels:    cacheLabels,
+	}, &exporterStatusGaugeVecs)
+
+	glusterExporterCLICommands = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_cli_commands_total",
+		Help:      "No of gluster CLI commands run, including the retries",
+		LongHelp:  "",
+		Labels:    cliLabels,
+	}, &exporterStatusCounterVecs)
+
+	glusterExporterCLIRetries = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_cli_retries_total",
+		Help:      "No of gluster CLI commands retried as glusterd was busy",
+		LongHelp:  "No of gluster CLI commands retried as they failed with `Another transaction is in progress` or `Locking failed` errors.",
+		Labels:    cliLabels,
+	}, &exporterStatusCounterVecs)
+
+	glusterExporterCLIQueueWait = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_cli_queue_wait_seconds_total",
+		Help:      "Total time the gluster CLI commands waited in the queue to be run",
+		LongHelp:  "Total time the gluster CLI commands waited in the queue to be run, as no more than `gluster-cli-concurrency` commands are run concurrently. Average wait time is `rate(gluster_exporter_cli_queue_wait_seconds_total[5m]) / rate(gluster_exporter_cli_commands_total[5m])`.",
+		Labels:    cliLabels,
+	}, &exporterStatusCounterVecs)
+
+	glusterExporterCLIQueueLength = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_cli_queue_length",
+		Help:      "No of gluster CLI commands waiting in the queue to be run",
+		LongHelp:  "",
+		Labels:    exporterLabels,
+	}, &exporterStatusGaugeVecs)
+
+	glusterExporterCLITimeouts = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "exporter_cli_timeouts_total",
+		Help:      "No of gluster CLI commands killed on timeout",
+		LongHelp:  "No of gluster CLI commands killed as they didn't complete within the configured `timeout`, usually when glusterd holds a cluster lock.",
+		Labels:    cliLabels,
+	}, &exporterStatusCounterVecs)
+)
+
+func getExporterLabels() prometheus.Labels {
+	return prometheus.Labels{
+		"instance": InstanceFQDN,
+	}
+}
+
+// SetConfigLoaded records the successful load of the
+// configuration when the exporter is started
+func SetConfigLoaded() {
+	exporterGaugeVecs[glusterExporterReloadSuccessful].Set(getExporterLabels(), 1)
+	exporterGaugeVecs[glusterExporterReloadSuccessTime].Set(getExporterLabels(),
+		float64(time.Now().UnixNano())/1e9)
+	for _, result := range []string{"success", "failure"} {
+		lbls := getExporterLabels()
+		lbls["result"] = result
+		exporterCounterVecs[glusterExporterReloads].Add(lbls, 0)
+	}
+}
+
+// SetConfigReloaded records the result of a configuration reload
+func SetConfigReloaded(success bool) {
+	lbls := getExporterLabels()
+	if success {
+		lbls["result"] = "success"
+		exporterGaugeVecs[glusterExporterReloadSuccessful].Set(getExporterLabels(), 1)
+		exporterGaugeVecs[glusterExporterReloadSuccessTime].Set(getExporterLabels(),
+			float64(time.Now().UnixNano())/1e9)
+	} else {
+		lbls["result"] = "failure"
+		exporterGaugeVecs[glusterExporterReloadSuccessful].Set(getExporterLabels(), 0)
+	}
+	exporterCounterVecs[glusterExporterReloads].Inc(lbls)
+}
+
+func exporterStatus(gluster glusterutils.GInterface) error {
+	// Reset all vecs to not export stale information
+	for _, gaugeVec := range exporterStatusGaugeVecs {
+		gaugeVec.RemoveStaleMetrics()
+	}
+	for _, counterVec := range exporterStatusCounterVecs {
+		counterVec.RemoveStaleMetrics()
+	}
+
+	for _, status := range glusterutils.GD2EndpointsStatus(gluster) {
+		lbls := getExporterLabels()
+		lbls["endpoint"] = status.Endpoint
+		exporterStatusGaugeVecs[glusterExporterGD2EndpointActive].Set(lbls, boolToFloat64(status.Active))
+		exporterStatusGaugeVecs[glusterExporterGD2EndpointUp].Set(lbls, boolToFloat64(status.Up))
+		exporterStatusCounterVecs[glusterExporterGD2EndpointFailovers].Set(lbls, float64(status.Failovers))
+	}
+
+	for funcName, stat := range glusterutils.CacheStats(gluster) {
+		lbls := getExporterLabels()
+		lbls["function"] = funcName
+		exporterStatusCounterVecs[glusterExporterCacheHits].Set(lbls, float64(stat.Hits))
+		exporterStatusCounterVecs[glusterExporterCacheMisses].Set(lbls, float64(stat.Misses))
+		exporterStatusCounterVecs[glusterExporterCacheShared].Set(lbls, float64(stat.Shared))
+		exporterStatusCounterVecs[glusterExporterCacheNegativeHits].Set(lbls, float64(stat.NegativeHits))
+		exporterStatusCounterVecs[glusterExporterCacheStaleServed].Set(lbls, float64(stat.StaleServed))
+		exporterStatusGaugeVecs[glusterExporterCacheLastStaleAge].Set(lbls, stat.LastStaleAge.Seconds())
+	}
+
+	for subcmd, stat := range glusterutils.CLIStats() {
+		lbls := getExporterLabels()
+		lbls["command"] = subcmd
+		exporterStatusCounterVecs[glusterExporterCLICommands].Set(lbls, float64(stat.Commands))
+		exporterStatusCounterVecs[glusterExporterCLIRetries].Set(lbls, float64(stat.Retries))
+		exporterStatusCounterVecs[glusterExporterCLITimeouts].Set(lbls, float64(stat.Timeouts))
+		exporterStatusCounterVecs[glusterExporterCLIQueueWait].Set(lbls, stat.QueueWait.Seconds())
+	}
+	exporterStatusGaugeVecs[glusterExporterCLIQueueLength].Set(getExporterLabels(),
+		float64(glusterutils.CLIQueueLength()))
+	return nil
+}
+
+func init() {
+	registerMetric("gluster_exporter", exporterStatus, resetVecs(exporterStatusGaugeVecs, exporterStatusCounterVecs))
+}
@@ -1,14 +1,8 @@
 package metrics
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
-	"io"
-	"strconv"
-	"strings"
-
 	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+	"github.com/gluster/gluster-prometheus/pkg/lvm"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 )
@@ -21,78 +15,26 @@ type PeerMetrics struct {
 	VGCount          int            // no: of Volume Groups
 }
 
-type myVGDetails struct {
-	LVUUID     string `json:"lv_uuid"`
-	LVName     string `json:"lv_name"`
-	PoolLV     string `json:"pool_lv"`
-	VGName     string `json:"vg_name"`
-	LVPath     string `json:"lv_path"`
-	LVCount    string `json:"lv_count"`
-	PVCount    string `json:"pv_count"`
-	PoolLVUUID string `json:"pool_lv_uuid"`
-	LVAttr     string `json:"lv_attr"`
-}
-
 // NewPeerMetrics : provides a way to get the consolidated metrics (such PV, LV, VG counts)
 func NewPeerMetrics() (*PeerMetrics, error) {
-	cmdStr := "lvm vgs --noheading --reportformat=json -o lv_uuid,lv_name,pool_lv,vg_name,lv_path,lv_count,pv_count,pool_lv_uuid,lv_attr"
-	outBs, err := glusterutils.ExecuteCmd(cmdStr)
+	report, err := lvm.GetReport()
 	if err != nil {
 		return nil, err
 	}
-	dec := json.NewDecoder(bytes.NewReader(outBs))
-	var vgs []myVGDetails
-	// collect the details from the JSON output
-	for {
-		t, decErr := dec.Token()
-		if decErr == io.EOF {
-			break
-		}
-		if decErr != nil {
-			err = errors.New("Unable to parse JSON output: " + decErr.Error())
-			return nil, err
-		}
-		// if the token is 'vg', collect/decode the details into VGDetails array
-		if t == "vg" {
-			decErr = dec.Decode(&vgs)
-		}
-		if decErr != nil {
-			err = errors.New("JSON output changed, parse failed: " + decErr.Error())
-			return nil, err
-		}
-	}
 	pMetrics := &PeerMetrics{
 		PVCount:          0,
-		VGCount:          0,
+		VGCount:          len(report.VGs),
 		LVCountMap:       make(map[string]int),
 		ThinPoolCountMap: make(map[string]int),
 	}
-	var vgMap = make(map[string]myVGDetails)
-	for _, vg := range vgs {
-		// collect the unique vgs into the map
-		if _, ok := vgMap[vg.VGName]; !ok {
-			vgMap[vg.VGName] = vg
-			// increment the VG counter, for each new Volume Group
-			pMetrics.VGCount++
-			// if there is no error while integer conversion, add that number
-			if count, convErr := strconv.Atoi(strings.TrimSpace(vg.PVCount)); convErr == nil {
-				pMetrics.PVCount += count
-			}
-			// by default set the LV count to Zero
-			pMetrics.LVCountMap[vg.VGName] = 0
-			if count, convErr := strconv.Atoi(strings.TrimSpace(vg.LVCount)); convErr == nil {
-				// if there are no errors, update the LV count
-				pMetrics.LVCountMap[vg.VGName] = count
-			}
-		}
-		// before adding into 'thinPoolMap', check the attribute
-		// if attribute string starts with 't', consider it as thinpool
-		//
-		// converting to a rune array because of 'utf-8' enconded
-		// string handling in 'Go'
-		if len(vg.LVAttr) > 0 && []rune(vg.LVAttr)[0] == 't' {
+	for _, vg := range report.VGs {
+		pMetrics.PVCount += vg.PVCount
+		pMetrics.LVCountMap[vg.Name] = vg.LVCount
+	}
+	for _, lv := range report.LVs {
+		if lv.IsThinPool() {
 			// increment the thin pool count for that particular VG
-			pMetrics.ThinPoolCountMap[vg.VGName]++
+			pMetrics.ThinPoolCountMap[lv.VGName]++
 		}
 	}
 	return pMetrics, nil
@@ -220,5 +162,5 @@ func peerCounts(gluster glusterutils.GInterface) (err error) {
 }
 
 func init() {
-	registerMetric("gluster_peer_counts", peerCounts)
+	registerMetric("gluster_peer_counts", peerCounts, resetVecs(peerCountsGaugeVecs, nil))
 }
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 )
@@ -28,7 +29,27 @@ var (
 		},
 	}
 
-	peerGaugeVecs = make(map[string]*ExportedGaugeVec)
+	peerStateMetricLabels = append(append([]MetricLabel{}, peerSCMetricLabels...), MetricLabel{
+		Name: "state",
+		Help: "Name of the peer state(Ex: `Peer in Cluster`, `Peer Rejected`)",
+	})
+	peerAddressMetricLabels = []MetricLabel{
+		{
+			Name: "instance",
+			Help: "Hostname of the gluster-prometheus instance providing this metric",
+		},
+		{
+			Name: "uuid",
+			Help: "Uuid of the peer for which data is collected",
+		},
+		{
+			Name: "address",
+			Help: "Address of the peer",
+		},
+	}
+
+	peerGaugeVecs   = make(map[string]*ExportedGaugeVec)
+	peerCounterVecs = make(map[string]*ExportedCounterVec)
 
 	glusterPeerCount = registerExportedGaugeVec(Metric{
 		Namespace: "gluster",
@@ -50,13 +71,55 @@ var (
 		Help:      "Peer connection status",
 		Labels:    peerSCMetricLabels,
 	}, &peerGaugeVecs)
+
+	glusterPeerState = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "peer_state",
+		Help:      "Peer state (1-current state, 0-otherwise)",
+		LongHelp:  "Peer state of glusterd. One metric is exported for each of the known peer states, value is 1 for the current state of the peer.",
+		Labels:    peerStateMetricLabels,
+	}, &peerGaugeVecs)
+
+	glusterPeerAddressInfo = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "peer_address_info",
+		Help:      "Addresses of the peer, value is always 1",
+		LongHelp:  "Addresses of the peer, one metric is exported for each address of the peer. `hostname` label of the other peer metrics is the first address of the peer, or the peer uuid if the peer has no addresses.",
+		Labels:    peerAddressMetricLabels,
+	}, &peerGaugeVecs)
+
+	glusterPeerStateTransitions = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "peer_state_transitions_total",
+		Help:      "No of peer state changes observed by the exporter",
+		LongHelp:  "",
+		Labels:    peerSCMetricLabels,
+	}, &peerCounterVecs)
+
+	// lastPeerStates tracks the state of the peers seen in
+	// the previous cycle, indexed by peer uuid
+	lastPeerStates = make(map[string]string)
 )
 
+// getPeerStates returns the list of peer states to be exported, which
+// includes the current state of the peer if it is not a known state
+func getPeerStates(current string) []string {
+	for _, state := range glusterconsts.PeerStatesGD1 {
+		if state == current {
+			return glusterconsts.PeerStatesGD1
+		}
+	}
+	return append(append([]string{}, glusterconsts.PeerStatesGD1...), current)
+}
+
 func peerInfo(gluster glusterutils.GInterface) (err error) {
 	// Reset all vecs to not export stale information
 	for _, gaugeVec := range peerGaugeVecs {
 		gaugeVec.RemoveStaleMetrics()
 	}
+	for _, counterVec := range peerCounterVecs {
+		counterVec.RemoveStaleMetrics()
+	}
 
 	var peerID string
 
@@ -79,12 +142,20 @@ func peerInfo(gluster glusterutils.GInterface) (err error) {
 	peerGaugeVecs[glusterPeerCount].Set(peerCountLabels, float64(len(peers)))
 
 	var connected int
+	seenPeers := make(map[string]bool)
 	for _, peer := range peers {
 		peerSCLabels := prometheus.Labels{
 			"instance": InstanceFQDN,
-			"hostname": peer.PeerAddresses[0],
+			"hostname": peer.Hostname(),
 			"uuid":     peer.ID,
 		}
+		for _, address := range peer.PeerAddresses {
+			peerGaugeVecs[glusterPeerAddressInfo].Set(prometheus.Labels{
+				"instance": InstanceFQDN,
+				"uuid":     peer.ID,
+				"address":  address,
+			}, 1)
+		}
 		if peer.Online {
 			connected = 1
 		} else {
@@ -97,10 +168,43 @@ func peerInfo(gluster glusterutils.GInterface) (err error) {
 			peerGaugeVecs[glusterPeerStatus].Set(peerSCLabels, float64(peer.Gd1State))
 		}
 		peerGaugeVecs[glusterPeerConnected].Set(peerSCLabels, float64(connected))
+
+		if peer.State == "" {
+			// peer states are available only with GD1 backend
+			continue
+		}
+		seenPeers[peer.ID] = true
+		for _, state := range getPeerStates(peer.State) {
+			stateLabels := prometheus.Labels{
+				"instance": InstanceFQDN,
+				"hostname": peer.Hostname(),
+				"uuid":     peer.ID,
+				"state":    state,
+			}
+			var current float64
+			if state == peer.State {
+				current = 1
+			}
+			peerGaugeVecs[glusterPeerState].Set(stateLabels, current)
+		}
+		lastState, ok := lastPeerStates[peer.ID]
+		if ok && lastState != peer.State {
+			peerCounterVecs[glusterPeerStateTransitions].Inc(peerSCLabels)
+		} else {
+			// export the series with zero transitions
+			peerCounterVecs[glusterPeerStateTransitions].Add(peerSCLabels, 0)
+		}
+		lastPeerStates[peer.ID] = peer.State
+	}
+	// forget the peers which are detached from the cluster
+	for id := range lastPeerStates {
+		if !seenPeers[id] {
+			delete(lastPeerStates, id)
+		}
 	}
 	return nil
 }
 
 func init() {
-	registerMetric("gluster_peer_info", peerInfo)
+	registerMetric("gluster_peer_info", peerInfo, resetVecs(peerGaugeVecs, peerCounterVecs))
 }
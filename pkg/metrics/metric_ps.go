@@ -1,25 +1,45 @@
 package metrics
 
 import (
+	"bufio"
+	"fmt"
 	"io/ioutil"
-	"os/exec"
+	"math"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	procDir = "/proc"
+	// userHZ is the clock tick used for the times in /proc/<pid>/stat,
+	// fixed at 100 for all the architectures Gluster runs on
+	userHZ = 100
+)
+
 var (
-	glusterProcs = []string{
+	// DefaultGlusterProcesses is the default list of process
+	// names monitored by the gluster_ps collector
+	DefaultGlusterProcesses = []string{
 		"glusterd",
 		"glusterfsd",
+		"glusterfs",
 		"glusterd2",
-		// TODO: Add more processes
+		"gsyncd",
 	}
 
+	// GlusterProcesses is the list of process names monitored by the
+	// gluster_ps collector, a process matches either by its command
+	// name or by the name of its executable or script
+	GlusterProcesses = DefaultGlusterProcesses
+
 	labels = []MetricLabel{
 		clusterIDLabel,
 		{
@@ -38,15 +58,30 @@ var (
 			Name: "name",
 			Help: "Name of the Gluster process(Ex: `glusterfsd`, `glusterd` etc)",
 		},
+		{
+			Name: "role",
+			Help: "Role of the Gluster process(Ex: `glusterd`, `brick`, `shd`, `quotad`, `nfs`, `gsyncd-monitor`, `gsyncd-worker`, `gsyncd-agent`, `fuse` etc)",
+		},
 	}
 
-	psGaugeVecs = make(map[string]*ExportedGaugeVec)
+	procInfoLabels = append(append([]MetricLabel{}, labels...), MetricLabel{
+		Name: "pid",
+		Help: "Process ID of the Gluster process",
+	})
+
+	ctxSwitchLabels = append(append([]MetricLabel{}, labels...), MetricLabel{
+		Name: "type",
+		Help: "Type of the context switch(`voluntary` or `nonvoluntary`)",
+	})
+
+	psGaugeVecs   = make(map[string]*ExportedGaugeVec)
+	psCounterVecs = make(map[string]*ExportedCounterVec)
 
 	glusterCPUPercentage = registerExportedGaugeVec(Metric{
 		Namespace: "gluster",
 		Name:      "cpu_percentage",
 		Help:      "CPU Percentage used by Gluster processes",
-		LongHelp:  "CPU percentage of Gluster process. One metric will be exposed for each process. Note: values of labels will be empty if not applicable to that process. For example, glusterd process will not have labels for volume or brick_path. It is the CPU time used divided by the time the process has been running (cputime/realtime ratio), expressed as a percentage.",
+		LongHelp:  "CPU percentage of Gluster process. One metric will be exposed for each process, the processes with the same labels like the FUSE mounts of a volume are summed up. Note: values of labels will be empty if not applicable to that process. For example, glusterd process will not have labels for volume or brick_path. It is the CPU time used divided by the time the process has been running (cputime/realtime ratio), expressed as a percentage.",
 		Labels:    labels,
 	}, &psGaugeVecs)
 
@@ -54,7 +89,7 @@ var (
 		Namespace: "gluster",
 		Name:      "memory_percentage",
 		Help:      "Memory Percentage used by Gluster processes",
-		LongHelp:  "Memory percentage of Gluster process. One metric will be exposed for each process. Note: values of labels will be empty if not applicable to that process. For example, glusterd process will not have labels for volume or brick_path. It is the ratio of the process's resident set size to the physical memory on the machine, expressed as a percentage",
+		LongHelp:  "Memory percentage of Gluster process. One metric will be exposed for each process, the processes with the same labels like the FUSE mounts of a volume are summed up. Note: values of labels will be empty if not applicable to that process. For example, glusterd process will not have labels for volume or brick_path. It is the ratio of the process's resident set size to the physical memory on the machine, expressed as a percentage",
 		Labels:    labels,
 	}, &psGaugeVecs)
 
@@ -62,7 +97,7 @@ var (
 		Namespace: "gluster",
 		Name:      "resident_memory_bytes",
 		Help:      "Resident Memory of Gluster processes in bytes",
-		LongHelp:  "Resident Memory of Gluster process in bytes. One metric will be exposed for each process. Note: values of labels will be empty if not applicable to that process. For example, glusterd process will not have labels for volume or brick_path.",
+		LongHelp:  "Resident Memory of Gluster process in bytes. One metric will be exposed for each process, the processes with the same labels like the FUSE mounts of a volume are summed up. Note: values of labels will be empty if not applicable to that process. For example, glusterd process will not have labels for volume or brick_path.",
 		Labels:    labels,
 	}, &psGaugeVecs)
 
@@ -70,7 +105,7 @@ var (
 		Namespace: "gluster",
 		Name:      "virtual_memory_bytes",
 		Help:      "Virtual Memory of Gluster processes in bytes",
-		LongHelp:  "Virtual Memory of Gluster process in bytes. One metric will be exposed for each process. Note: values of labels will be empty if not applicable to that process. For example, glusterd process will not have labels for volume or brick_path.",
+		LongHelp:  "Virtual Memory of Gluster process in bytes. One metric will be exposed for each process, the processes with the same labels like the FUSE mounts of a volume are summed up. Note: values of labels will be empty if not applicable to that process. For example, glusterd process will not have labels for volume or brick_path.",
 		Labels:    labels,
 	}, &psGaugeVecs)
 
@@ -78,15 +113,106 @@ var (
 		Namespace: "gluster",
 		Name:      "elapsed_time_seconds",
 		Help:      "Elapsed Time of Gluster processes in seconds",
-		LongHelp:  "Elapsed Time or Uptime of Gluster processes in seconds. One metric will be exposed for each process. Note: values of labels will be empty if not applicable to that process. For example, glusterd process will not have labels for volume or brick_path.",
+		LongHelp:  "Elapsed Time or Uptime of Gluster processes in seconds. One metric will be exposed for each process, the oldest of the processes with the same labels like the FUSE mounts of a volume is reported. Note: values of labels will be empty if not applicable to that process. For example, glusterd process will not have labels for volume or brick_path.",
+		Labels:    labels,
+	}, &psGaugeVecs)
+
+	glusterThreads = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "threads",
+		Help:      "No of threads of Gluster processes",
+		LongHelp:  "",
 		Labels:    labels,
 	}, &psGaugeVecs)
+
+	glusterOpenFDs = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "open_fds",
+		Help:      "No of open file descriptors of Gluster processes",
+		LongHelp:  "",
+		Labels:    labels,
+	}, &psGaugeVecs)
+
+	glusterProcessInfo = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "process_info",
+		Help:      "Process ID of Gluster processes",
+		LongHelp:  "Always 1, exposed for each Gluster process with its process ID. Processes with the same labels otherwise, like the FUSE mounts of a volume, are summed up in the other process metrics.",
+		Labels:    procInfoLabels,
+	}, &psGaugeVecs)
+
+	glusterCPUSeconds = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "cpu_seconds_total",
+		Help:      "Total user and system CPU time of Gluster processes in seconds",
+		LongHelp:  "",
+		Labels:    labels,
+	}, &psCounterVecs)
+
+	glusterContextSwitches = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "context_switches_total",
+		Help:      "No of context switches of Gluster processes",
+		LongHelp:  "",
+		Labels:    ctxSwitchLabels,
+	}, &psCounterVecs)
+
+	glusterIOReadBytes = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "io_read_bytes_total",
+		Help:      "Bytes read from the storage layer by Gluster processes",
+		LongHelp:  "Bytes read from the storage layer by Gluster processes. Requires the exporter to run as root to read `/proc/<pid>/io` of the Gluster processes.",
+		Labels:    labels,
+	}, &psCounterVecs)
+
+	glusterIOWriteBytes = registerExportedCounterVec(Metric{
+		Namespace: "gluster",
+		Name:      "io_write_bytes_total",
+		Help:      "Bytes written to the storage layer by Gluster processes",
+		LongHelp:  "Bytes written to the storage layer by Gluster processes. Requires the exporter to run as root to read `/proc/<pid>/io` of the Gluster processes.",
+		Labels:    labels,
+	}, &psCounterVecs)
 )
 
+// procStat represents the details read from /proc/<pid>/stat
+type procStat struct {
+	PID        int
+	Comm       string
+	UTime      uint64 // in clock ticks
+	STime      uint64 // in clock ticks
+	NumThreads int64
+	StartTime  uint64 // in clock ticks after boot
+	VSize      uint64 // in bytes
+	RSS        int64  // in pages
+}
+
+// procsStat represents the sum of the details of
+// the processes with the same labels
+type procsStat struct {
+	labels           prometheus.Labels
+	cpuPercentage    float64
+	memoryPercentage float64
+	residentMemory   float64
+	virtualMemory    float64
+	// elapsed time of the oldest process
+	elapsed    float64
+	threads    float64
+	cpuSeconds float64
+	openFDs    float64
+	openFDsOK  bool
+
+	voluntaryCtxSwitches    float64
+	nonvoluntaryCtxSwitches float64
+	ctxSwitchesOK           bool
+	ioReadBytes             float64
+	ioWriteBytes            float64
+	ioOK                    bool
+}
+
 func getCmdLine(pid string) ([]string, error) {
 	var args []string
 
-	out, err := ioutil.ReadFile(filepath.Clean("/proc/" + pid + "/cmdline"))
+	out, err := ioutil.ReadFile(filepath.Clean(procDir + "/" + pid + "/cmdline"))
 	if err != nil {
 		return args, err
 	}
@@ -94,47 +220,232 @@ func getCmdLine(pid string) ([]string, error) {
 	return strings.Split(strings.Trim(string(out), "\x00"), "\x00"), nil
 }
 
-func getGlusterdLabels(peerID, cmd string) prometheus.Labels {
-	return prometheus.Labels{
-		"cluster_id": ClusterID,
-		"name":       cmd,
-		"volume":     "",
-		"peerid":     peerID,
-		"brick_path": "",
+func readProcStat(pid string) (procStat, error) {
+	var stat procStat
+	out, err := ioutil.ReadFile(filepath.Clean(procDir + "/" + pid + "/stat"))
+	if err != nil {
+		return stat, err
+	}
+	// Sample data:
+	// 6959 (glusterfsd) S 1 6959 6959 0 -1 ...
+	// command name can contain spaces and parentheses, so
+	// parse it using the first '(' and the last ')'
+	data := string(out)
+	start := strings.IndexByte(data, '(')
+	end := strings.LastIndexByte(data, ')')
+	if start < 0 || end < start {
+		return stat, fmt.Errorf("invalid stat format of pid %s", pid)
+	}
+	if stat.PID, err = strconv.Atoi(strings.TrimSpace(data[:start])); err != nil {
+		return stat, err
+	}
+	stat.Comm = data[start+1 : end]
+	// fields after the command name, starting from 'state'(3rd field)
+	fields := strings.Fields(data[end+1:])
+	if len(fields) < 22 {
+		return stat, fmt.Errorf("invalid stat format of pid %s", pid)
 	}
+	parsers := []struct {
+		idx int
+		dst interface{}
+	}{
+		{11, &stat.UTime},      // 14th field
+		{12, &stat.STime},      // 15th field
+		{17, &stat.NumThreads}, // 20th field
+		{19, &stat.StartTime},  // 22nd field
+		{20, &stat.VSize},      // 23rd field
+		{21, &stat.RSS},        // 24th field
+	}
+	for _, p := range parsers {
+		switch dst := p.dst.(type) {
+		case *uint64:
+			*dst, err = strconv.ParseUint(fields[p.idx], 10, 64)
+		case *int64:
+			*dst, err = strconv.ParseInt(fields[p.idx], 10, 64)
+		}
+		if err != nil {
+			return stat, err
+		}
+	}
+	return stat, nil
 }
 
-func getGlusterFsdLabels(peerID, cmd string, args []string) prometheus.Labels {
-	bpath := ""
-	volume := ""
+// readProcKeyValues reads the files like /proc/<pid>/status, /proc/<pid>/io
+// or /proc/meminfo, which contain a `key: value` pair in each line
+func readProcKeyValues(path string) (map[string]uint64, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		fields := strings.Fields(parts[1])
+		if len(fields) == 0 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+			values[parts[0]] = v
+		}
+	}
+	return values, scanner.Err()
+}
 
-	prevArg := ""
+func countOpenFDs(pid string) (int, error) {
+	fds, err := ioutil.ReadDir(filepath.Clean(procDir + "/" + pid + "/fd"))
+	if err != nil {
+		return 0, err
+	}
+	return len(fds), nil
+}
 
+// bootTime returns the system boot time from /proc/stat
+func bootTime() (time.Time, error) {
+	out, err := ioutil.ReadFile(procDir + "/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "btime" {
+			btime, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(btime, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("btime not found in %s/stat", procDir)
+}
+
+// procStartTime returns the start time of a process
+func procStartTime(boot time.Time, stat procStat) time.Time {
+	return boot.Add(time.Duration(stat.StartTime) * time.Second / userHZ)
+}
+
+// matchGlusterProcess returns the name of the monitored Gluster process
+// if the process matches with any name in the given list
+func matchGlusterProcess(comm string, args []string, names []string) (string, bool) {
+	for _, name := range names {
+		if comm == name {
+			return name, true
+		}
+	}
+	// processes like gsyncd run as scripts, so match the
+	// executable and the script names as well
+	for idx := 0; idx < len(args) && idx < 2; idx++ {
+		base := strings.TrimSuffix(filepath.Base(args[idx]), ".py")
+		for _, name := range names {
+			if base == name {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// getArgValue returns the value of the command line option, which
+// is provided either as `--opt value` or as `--opt=value`
+func getArgValue(args []string, opts ...string) string {
+	for idx, a := range args {
+		for _, opt := range opts {
+			if a == opt && idx+1 < len(args) {
+				return args[idx+1]
+			}
+			if strings.HasPrefix(a, opt+"=") {
+				return strings.TrimPrefix(a, opt+"=")
+			}
+		}
+	}
+	return ""
+}
+
+func hasArg(args []string, opt string) bool {
 	for _, a := range args {
-		if prevArg == "--brick-name" {
-			bpath = a
-		} else if prevArg == "--volfile-id" {
-			volume = strings.Split(a, ".")[0]
+		if a == opt {
+			return true
 		}
-		prevArg = a
 	}
+	return false
+}
 
-	return prometheus.Labels{
-		"cluster_id": ClusterID,
-		"name":       cmd,
-		"volume":     volume,
-		"peerid":     peerID,
-		"brick_path": bpath,
+// getGlusterfsRoleAndVolume detects the role of a glusterfs process from
+// its command line. glusterfs binary runs all the daemons and clients
+// except bricks, for example
+// self-heal daemon: glusterfs -s localhost --volfile-id gluster/glustershd ...
+// FUSE client: glusterfs --volfile-server=host --volfile-id=/vol /mnt/vol
+func getGlusterfsRoleAndVolume(args []string) (string, string) {
+	volfileID := getArgValue(args, "--volfile-id")
+	parts := strings.SplitN(volfileID, "/", 2)
+	if len(parts) == 2 {
+		switch parts[0] {
+		case "gluster":
+			// node level daemons, not specific to any volume
+			switch parts[1] {
+			case "glustershd":
+				return "shd", ""
+			case "quotad":
+				return "quotad", ""
+			case "nfs":
+				return "nfs", ""
+			case "bitd":
+				return "bitd", ""
+			case "scrub":
+				return "scrubber", ""
+			}
+		case "shd":
+			return "shd", parts[1]
+		case "snapd":
+			return "snapd", parts[1]
+		}
 	}
+	volume := strings.TrimPrefix(volfileID, "/")
+	// geo-replication mounts the volumes with aux-gfid-mount
+	if hasArg(args, "--aux-gfid-mount") {
+		return "gsyncd", volume
+	}
+	return "fuse", volume
 }
 
-func getUnknownLabels(peerID, cmd string) prometheus.Labels {
+func getProcLabels(peerID, name string, args []string) prometheus.Labels {
+	bpath := ""
+	volume := ""
+	role := name
+
+	switch name {
+	case "glusterfsd":
+		role = "brick"
+		bpath = getArgValue(args, "--brick-name")
+		volume = strings.Split(getArgValue(args, "--volfile-id"), ".")[0]
+	case "glusterfs":
+		role, volume = getGlusterfsRoleAndVolume(args)
+	case "gsyncd":
+		// gsyncd processes are started as,
+		// gsyncd.py worker <mastervol> <slavehost>::<slavevol> --local-path <brick> ...
+		// a monitor runs per session, and a worker and an agent per brick
+		for idx, a := range args {
+			if (a == "monitor" || a == "worker" || a == "agent") && idx+1 < len(args) {
+				role = "gsyncd-" + a
+				volume = args[idx+1]
+				bpath = getArgValue(args, "--local-path")
+				break
+			}
+		}
+	}
+
 	return prometheus.Labels{
 		"cluster_id": ClusterID,
-		"name":       cmd,
-		"volume":     "",
+		"name":       name,
+		"volume":     volume,
 		"peerid":     peerID,
-		"brick_path": "",
+		"brick_path": bpath,
+		"role":       role,
 	}
 }
 
@@ -143,132 +454,150 @@ func ps(gluster glusterutils.GInterface) error {
 	for _, gaugeVec := range psGaugeVecs {
 		gaugeVec.RemoveStaleMetrics()
 	}
+	for _, counterVec := range psCounterVecs {
+		counterVec.RemoveStaleMetrics()
+	}
 
-	args := []string{
-		"--no-header", // No header in the output
-		"-ww",         // To set unlimited width to avoid crop
-		"-o",          // Output Format
-		"pid,pcpu,pmem,rsz,vsz,etimes,comm",
-		"-C",
-		strings.Join(glusterProcs, ","),
+	pids, err := ioutil.ReadDir(procDir)
+	if err != nil {
+		// Return without exporting metrics in this cycle
+		return err
 	}
 
-	out, err := exec.Command("ps", args...).Output() // #nosec
+	boot, err := bootTime()
+	if err != nil {
+		return err
+	}
 
+	meminfo, err := readProcKeyValues(procDir + "/meminfo")
 	if err != nil {
-		// Return without exporting metrics in this cycle
 		return err
 	}
+	// MemTotal is in kilo bytes
+	memTotal := float64(meminfo["MemTotal"] * 1024)
+	pageSize := float64(os.Getpagesize())
 
 	peerID, err := gluster.LocalPeerID()
 	if err != nil {
 		return err
 	}
 
-	for _, line := range strings.Split(string(out), "\n") {
-		// Sample data:
-		// 6959  0.0  0.6 12840 713660  504076 glusterfs
-		lineDataTmp := strings.Split(line, " ")
-		lineData := []string{}
-		for _, d := range lineDataTmp {
-			if strings.Trim(d, " ") == "" {
-				continue
-			}
-			lineData = append(lineData, d)
-		}
-
-		if len(lineData) < 7 {
+	// processes with the same labels, like the FUSE mounts of
+	// a volume, are summed up as they can't be told apart
+	procsByLabels := make(map[uint64]*procsStat)
+	now := time.Now()
+	for _, p := range pids {
+		pid := p.Name()
+		if _, err := strconv.Atoi(pid); err != nil {
+			// not a process directory
 			continue
 		}
-		cmdlineArgs, err := getCmdLine(lineData[0])
+		stat, err := readProcStat(pid)
 		if err != nil {
-			log.WithError(err).WithFields(log.Fields{
-				"command": lineData[6],
-				"pid":     lineData[0],
-			}).Debug("Error getting command line")
+			// process may have exited
 			continue
 		}
-
-		if len(cmdlineArgs) == 0 {
+		cmdlineArgs, err := getCmdLine(pid)
+		if err != nil || len(cmdlineArgs) == 0 {
 			// No cmdline file, may be that process died
 			continue
 		}
-
-		var lbls prometheus.Labels
-		switch lineData[6] {
-		case "glusterd":
-			lbls = getGlusterdLabels(peerID, lineData[6])
-		case "glusterd2":
-			lbls = getGlusterdLabels(peerID, lineData[6])
-		case "glusterfsd":
-			lbls = getGlusterFsdLabels(peerID, lineData[6], cmdlineArgs)
-		default:
-			lbls = getUnknownLabels(peerID, lineData[6])
+		name, ok := matchGlusterProcess(stat.Comm, cmdlineArgs, GlusterProcesses)
+		if !ok {
+			continue
 		}
 
-		pcpu, err := strconv.ParseFloat(lineData[1], 64)
-		if err != nil {
-			log.WithError(err).WithFields(log.Fields{
-				"value":   lineData[1],
-				"command": lineData[6],
-				"pid":     lineData[0],
-			}).Debug("Unable to parse pcpu value")
-			continue
+		lbls := getProcLabels(peerID, name, cmdlineArgs)
+		infoLbls := getProcLabels(peerID, name, cmdlineArgs)
+		infoLbls["pid"] = pid
+		psGaugeVecs[glusterProcessInfo].Set(infoLbls, 1)
+
+		hash := model.LabelsToSignature(lbls)
+		procs, ok := procsByLabels[hash]
+		if !ok {
+			procs = &procsStat{labels: lbls, ctxSwitchesOK: true, ioOK: true}
+			procsByLabels[hash] = procs
 		}
 
-		pmem, err := strconv.ParseFloat(lineData[2], 64)
-		if err != nil {
-			log.WithError(err).WithFields(log.Fields{
-				"value":   lineData[2],
-				"command": lineData[6],
-				"pid":     lineData[0],
-			}).Debug("Unable to parse pmem value")
-			continue
+		cpuSeconds := float64(stat.UTime+stat.STime) / userHZ
+		elapsed := now.Sub(procStartTime(boot, stat)).Seconds()
+		if elapsed > 0 {
+			procs.cpuPercentage += cpuSeconds / elapsed * 100
 		}
-		rsz, err := strconv.ParseFloat(lineData[3], 64)
-		if err != nil {
+		procs.elapsed = math.Max(procs.elapsed, elapsed)
+		rss := float64(stat.RSS) * pageSize
+		procs.residentMemory += rss
+		if memTotal > 0 {
+			procs.memoryPercentage += rss / memTotal * 100
+		}
+		procs.virtualMemory += float64(stat.VSize)
+		procs.threads += float64(stat.NumThreads)
+		procs.cpuSeconds += cpuSeconds
+
+		if fds, err := countOpenFDs(pid); err == nil {
+			procs.openFDs += float64(fds)
+			procs.openFDsOK = true
+		} else {
 			log.WithError(err).WithFields(log.Fields{
-				"value":   lineData[3],
-				"command": lineData[6],
-				"pid":     lineData[0],
-			}).Debug("Unable to parse rsz value")
-			continue
+				"command": name,
+				"pid":     pid,
+			}).Debug("Unable to count open file descriptors")
 		}
 
-		vsz, err := strconv.ParseFloat(lineData[4], 64)
-		if err != nil {
-			log.WithError(err).WithFields(log.Fields{
-				"value":   lineData[4],
-				"command": lineData[6],
-				"pid":     lineData[0],
-			}).Debug("Unable to parse vsz value")
-			continue
+		// cumulative values are exported only if read for all the
+		// processes, otherwise the sum decreases and resets the counters
+		if status, err := readProcKeyValues(procDir + "/" + pid + "/status"); err == nil {
+			procs.voluntaryCtxSwitches += float64(status["voluntary_ctxt_switches"])
+			procs.nonvoluntaryCtxSwitches += float64(status["nonvoluntary_ctxt_switches"])
+		} else {
+			procs.ctxSwitchesOK = false
 		}
 
-		etimes, err := strconv.ParseFloat(lineData[5], 64)
-		if err != nil {
+		if io, err := readProcKeyValues(procDir + "/" + pid + "/io"); err == nil {
+			procs.ioReadBytes += float64(io["read_bytes"])
+			procs.ioWriteBytes += float64(io["write_bytes"])
+		} else {
+			procs.ioOK = false
 			log.WithError(err).WithFields(log.Fields{
-				"value":   lineData[5],
-				"command": lineData[6],
-				"pid":     lineData[0],
-			}).Debug("Unable to parse etimes value")
-			continue
+				"command": name,
+				"pid":     pid,
+			}).Debug("Unable to read I/O statistics")
 		}
+	}
 
-		// Convert to bytes from kilo bytes
-		vsz = vsz * 1024
-		rsz = rsz * 1024
-
-		// Update the Metrics
-		psGaugeVecs[glusterCPUPercentage].Set(lbls, pcpu)
-		psGaugeVecs[glusterMemoryPercentage].Set(lbls, pmem)
-		psGaugeVecs[glusterResidentMemory].Set(lbls, rsz)
-		psGaugeVecs[glusterVirtualMemory].Set(lbls, vsz)
-		psGaugeVecs[glusterElapsedTime].Set(lbls, etimes)
+	// Update the Metrics
+	for _, procs := range procsByLabels {
+		lbls := procs.labels
+		psGaugeVecs[glusterCPUPercentage].Set(lbls, procs.cpuPercentage)
+		psGaugeVecs[glusterMemoryPercentage].Set(lbls, procs.memoryPercentage)
+		psGaugeVecs[glusterResidentMemory].Set(lbls, procs.residentMemory)
+		psGaugeVecs[glusterVirtualMemory].Set(lbls, procs.virtualMemory)
+		psGaugeVecs[glusterElapsedTime].Set(lbls, procs.elapsed)
+		psGaugeVecs[glusterThreads].Set(lbls, procs.threads)
+		psCounterVecs[glusterCPUSeconds].Set(lbls, procs.cpuSeconds)
+		if procs.openFDsOK {
+			psGaugeVecs[glusterOpenFDs].Set(lbls, procs.openFDs)
+		}
+		if procs.ctxSwitchesOK {
+			for ctxType, value := range map[string]float64{
+				"voluntary":    procs.voluntaryCtxSwitches,
+				"nonvoluntary": procs.nonvoluntaryCtxSwitches,
+			} {
+				ctxLbls := prometheus.Labels{"type": ctxType}
+				for k, v := range lbls {
+					ctxLbls[k] = v
+				}
+				psCounterVecs[glusterContextSwitches].Set(ctxLbls, value)
+			}
+		}
+		if procs.ioOK {
+			psCounterVecs[glusterIOReadBytes].Set(lbls, procs.ioReadBytes)
+			psCounterVecs[glusterIOWriteBytes].Set(lbls, procs.ioWriteBytes)
+		}
 	}
 	return nil
 }
 
 func init() {
-	registerMetric("gluster_ps", ps)
+	registerMetric("gluster_ps", ps, resetVecs(psGaugeVecs, psCounterVecs))
 }
@@ -51,5 +51,5 @@ func quotas(gluster glusterutils.GInterface) (err error) {
 }
 
 func init() {
-	registerMetric("gluster_quotas", quotas)
+	registerMetric("gluster_quotas", quotas, resetVecs(quotasGaugeVers, nil))
 }
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// thin pool usage samples older than the window are
+	// not considered for the exhaustion forecast
+	thinPoolHistoryWindow = time.Hour
+	// minimum no of samples required for the forecast
+	thinPoolMinSamples = 3
+)
+
+var (
+	glusterThinPoolDataSecondsUntilFull = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "thinpool_data_seconds_until_full",
+		Help:      "Estimated seconds until the thin pool data is full",
+		LongHelp:  "Estimated seconds until the thin pool data is full, based on a linear regression of the thin pool data usage observed in the last hour. `+Inf` if the usage is not growing. Exported once enough samples are collected.",
+		Labels:    thinLvmLbls,
+	}, &brickGaugeVecs)
+
+	glusterThinPoolMetadataSecondsUntilFull = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "thinpool_metadata_seconds_until_full",
+		Help:      "Estimated seconds until the thin pool metadata is full",
+		LongHelp:  "Estimated seconds until the thin pool metadata is full, based on a linear regression of the thin pool metadata usage observed in the last hour. `+Inf` if the usage is not growing. Exported once enough samples are collected.",
+		Labels:    thinLvmLbls,
+	}, &brickGaugeVecs)
+
+	glusterThinPoolAutoextendThreshold = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "thinpool_autoextend_threshold_percent",
+		Help:      "Thin pool usage percentage above which the thin pool is autoextended",
+		LongHelp:  "Thin pool usage percentage above which the thin pool is autoextended, `activation/thin_pool_autoextend_threshold` setting of lvm.conf. 100 means autoextension is disabled.",
+		Labels:    thinLvmLbls,
+	}, &brickGaugeVecs)
+
+	glusterThinPoolAutoextendPercent = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "thinpool_autoextend_percent",
+		Help:      "Percentage of its size by which the thin pool is autoextended",
+		LongHelp:  "Percentage of its size by which the thin pool is autoextended, `activation/thin_pool_autoextend_percent` setting of lvm.conf.",
+		Labels:    thinLvmLbls,
+	}, &brickGaugeVecs)
+
+	// thinPoolHistory maintains the recent usage samples
+	// of the thin pools, indexed by VG and thin pool name
+	thinPoolHistory = make(map[string]*thinPoolSamples)
+)
+
+// thinPoolSample represents the thin pool usage at a point of time
+type thinPoolSample struct {
+	Time         time.Time
+	DataUsed     float64
+	MetadataUsed float64
+}
+
+type thinPoolSamples struct {
+	samples []thinPoolSample
+}
+
+func thinPoolKey(stat ThinPoolStat) string {
+	return stat.ThinPoolVGName + "/" + stat.ThinPoolName
+}
+
+// add records the usage of the thin pool, same report shared by
+// multiple bricks of the thin pool is recorded only once
+func (h *thinPoolSamples) add(stat ThinPoolStat) {
+	if n := len(h.samples); n > 0 && !stat.SampledAt.After(h.samples[n-1].Time) {
+		return
+	}
+	h.samples = append(h.samples, thinPoolSample{
+		Time:         stat.SampledAt,
+		DataUsed:     stat.ThinPoolDataUsed,
+		MetadataUsed: stat.ThinPoolMetadataUsed,
+	})
+	h.prune(stat.SampledAt)
+}
+
+// prune removes the samples older than the history window
+func (h *thinPoolSamples) prune(now time.Time) {
+	idx := 0
+	for idx < len(h.samples) && now.Sub(h.samples[idx].Time) > thinPoolHistoryWindow {
+		idx++
+	}
+	h.samples = h.samples[idx:]
+}
+
+// usageSlope returns the growth rate in bytes per second of the
+// usage using the least squares linear regression
+func (h *thinPoolSamples) usageSlope(usage func(thinPoolSample) float64) (float64, bool) {
+	n := float64(len(h.samples))
+	if len(h.samples) < thinPoolMinSamples {
+		return 0, false
+	}
+	start := h.samples[0].Time
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range h.samples {
+		x := sample.Time.Sub(start).Seconds()
+		y := usage(sample)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
+
+// secondsUntilFull returns the estimated seconds until the usage reaches total
+func secondsUntilFull(slope float64, used float64, total float64) float64 {
+	if slope <= 0 {
+		return math.Inf(1)
+	}
+	return math.Max(total-used, 0) / slope
+}
+
+// thinPoolForecast records the thin pool usage and returns the estimated
+// seconds until the data and metadata of the thin pool are full
+func thinPoolForecast(stat ThinPoolStat) (dataSecs float64, metadataSecs float64, ok bool) {
+	key := thinPoolKey(stat)
+	history, exists := thinPoolHistory[key]
+	if !exists {
+		history = &thinPoolSamples{}
+		thinPoolHistory[key] = history
+	}
+	history.add(stat)
+
+	dataSlope, ok := history.usageSlope(func(s thinPoolSample) float64 { return s.DataUsed })
+	if !ok {
+		return 0, 0, false
+	}
+	metadataSlope, ok := history.usageSlope(func(s thinPoolSample) float64 { return s.MetadataUsed })
+	if !ok {
+		return 0, 0, false
+	}
+	return secondsUntilFull(dataSlope, stat.ThinPoolDataUsed, stat.ThinPoolDataTotal),
+		secondsUntilFull(metadataSlope, stat.ThinPoolMetadataUsed, stat.ThinPoolMetadataTotal),
+		true
+}
+
+// pruneThinPoolHistory removes the history of the thin pools
+// which are not reported in the history window
+func pruneThinPoolHistory() {
+	now := time.Now()
+	for key, history := range thinPoolHistory {
+		history.prune(now)
+		if len(history.samples) == 0 {
+			delete(thinPoolHistory, key)
+		}
+	}
+}
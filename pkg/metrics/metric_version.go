@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	versionLbls = []MetricLabel{
+		clusterIDLabel,
+		{
+			Name: "peerid",
+			Help: "Peer ID",
+		},
+	}
+
+	versionInfoLbls = append(append([]MetricLabel{}, versionLbls...), MetricLabel{
+		Name: "version",
+		Help: "Version of the glusterfs installed",
+	})
+
+	clusterOpVersionLbls = []MetricLabel{
+		clusterIDLabel,
+	}
+
+	versionGaugeVecs = make(map[string]*ExportedGaugeVec)
+
+	glusterVersionInfo = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "version_info",
+		Help:      "Version of the glusterfs installed on the peer, value is always 1",
+		LongHelp:  "",
+		Labels:    versionInfoLbls,
+	}, &versionGaugeVecs)
+
+	glusterLocalOpVersion = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "local_op_version",
+		Help:      "Operating version of the glusterd on the peer",
+		LongHelp:  "Operating version of the glusterd on the peer, read from `glusterd.info`.",
+		Labels:    versionLbls,
+	}, &versionGaugeVecs)
+
+	glusterClusterOpVersion = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "cluster_op_version",
+		Help:      "Operating version of the cluster",
+		LongHelp:  "Operating version of the cluster, `cluster.op-version` global option. Lower than `gluster_cluster_max_op_version` once all the peers are upgraded, until the operating version is bumped.",
+		Labels:    clusterOpVersionLbls,
+	}, &versionGaugeVecs)
+
+	glusterClusterMaxOpVersion = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "cluster_max_op_version",
+		Help:      "Maximum operating version supported by all the peers of the cluster",
+		LongHelp:  "",
+		Labels:    clusterOpVersionLbls,
+	}, &versionGaugeVecs)
+)
+
+func getVersionLabels(peerID string) prometheus.Labels {
+	return prometheus.Labels{
+		"cluster_id": ClusterID,
+		"peerid":     peerID,
+	}
+}
+
+func version(gluster glusterutils.GInterface) error {
+	// Reset all vecs to not export stale information
+	for _, gaugeVec := range versionGaugeVecs {
+		gaugeVec.RemoveStaleMetrics()
+	}
+
+	peerID, err := gluster.LocalPeerID()
+	if err != nil {
+		return err
+	}
+
+	if ver, err := glusterutils.GetGlusterVersion(); err == nil {
+		lbls := getVersionLabels(peerID)
+		lbls["version"] = ver
+		versionGaugeVecs[glusterVersionInfo].Set(lbls, 1)
+	} else {
+		log.WithError(err).Debug("Unable to get the glusterfs version")
+	}
+
+	if opVersion, err := gluster.LocalOpVersion(); err == nil {
+		versionGaugeVecs[glusterLocalOpVersion].Set(getVersionLabels(peerID), float64(opVersion))
+	} else {
+		log.WithError(err).Debug("Unable to get the local operating version")
+	}
+
+	isLeader, err := gluster.IsLeader()
+	if err != nil {
+		log.WithError(err).Debug("Unable to find if the current node is leader")
+		return err
+	}
+	if !isLeader {
+		return nil
+	}
+
+	clusterOpVersion, err := gluster.ClusterOpVersion()
+	if err != nil {
+		return err
+	}
+	lbls := prometheus.Labels{
+		"cluster_id": ClusterID,
+	}
+	versionGaugeVecs[glusterClusterOpVersion].Set(lbls, float64(clusterOpVersion.OpVersion))
+	versionGaugeVecs[glusterClusterMaxOpVersion].Set(lbls, float64(clusterOpVersion.MaxOpVersion))
+	return nil
+}
+
+func init() {
+	registerMetric("gluster_version", version, resetVecs(versionGaugeVecs, nil))
+}
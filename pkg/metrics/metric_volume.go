@@ -487,6 +487,6 @@ func profileInfo(gluster glusterutils.GInterface) error {
 }
 
 func init() {
-	registerMetric("gluster_volume_heal", healCounts)
-	registerMetric("gluster_volume_profile", profileInfo)
+	registerMetric("gluster_volume_heal", healCounts, resetVecs(volumeHealGaugeVecs, nil))
+	registerMetric("gluster_volume_profile", profileInfo, resetVecs(volumeProfileGaugeVecs, nil))
 }
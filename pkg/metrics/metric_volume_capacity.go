@@ -0,0 +1,266 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	volumeCapacityLabels = []MetricLabel{
+		clusterIDLabel,
+		{
+			Name: "volume",
+			Help: "Volume Name",
+		},
+	}
+
+	volumeCapacityGaugeVecs = make(map[string]*ExportedGaugeVec)
+
+	glusterVolumeCapacityTotal = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "volume_capacity_total_bytes",
+		Help:      "Effective total capacity of gluster volume in bytes",
+		LongHelp:  "Effective total capacity of gluster volume in bytes, computed on the leader node from the status of all the bricks. Arbiter bricks are excluded and only the data bricks of disperse subvolumes are accounted.",
+		Labels:    volumeCapacityLabels,
+	}, &volumeCapacityGaugeVecs)
+
+	glusterVolumeCapacityUsed = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "volume_capacity_used_bytes",
+		Help:      "Effective used capacity of gluster volume in bytes",
+		LongHelp:  "",
+		Labels:    volumeCapacityLabels,
+	}, &volumeCapacityGaugeVecs)
+
+	glusterVolumeCapacityUsable = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "volume_capacity_usable_bytes",
+		Help:      "Effective usable capacity of gluster volume in bytes",
+		LongHelp:  "Effective total capacity of gluster volume in bytes excluding the space reserved on each brick by the `cluster.min-free-disk` volume option.",
+		Labels:    volumeCapacityLabels,
+	}, &volumeCapacityGaugeVecs)
+
+	glusterVolumeCapacitySubvolsUnavailable = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "volume_capacity_subvols_unavailable",
+		Help:      "No of subvolumes not accounted in volume capacity",
+		LongHelp:  "No of subvolumes not accounted in the volume capacity metrics, because none of their data bricks are online.",
+		Labels:    volumeCapacityLabels,
+	}, &volumeCapacityGaugeVecs)
+
+	glusterVolumeCapacitySubvolTotal = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "volume_capacity_subvol_total_bytes",
+		Help:      "Effective total capacity of gluster subvolume in bytes",
+		LongHelp:  "",
+		Labels:    subvolLabels,
+	}, &volumeCapacityGaugeVecs)
+
+	glusterVolumeCapacitySubvolUsed = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "volume_capacity_subvol_used_bytes",
+		Help:      "Effective used capacity of gluster subvolume in bytes",
+		LongHelp:  "",
+		Labels:    subvolLabels,
+	}, &volumeCapacityGaugeVecs)
+
+	glusterVolumeCapacitySubvolUsable = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "volume_capacity_subvol_usable_bytes",
+		Help:      "Effective usable capacity of gluster subvolume in bytes",
+		LongHelp:  "",
+		Labels:    subvolLabels,
+	}, &volumeCapacityGaugeVecs)
+)
+
+// byteUnits are the size suffixes accepted by gluster for size options
+var byteUnits = map[string]float64{
+	"":   1,
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+	"TB": 1 << 40,
+	"PB": 1 << 50,
+}
+
+// parseMinFreeDisk parses the value of `cluster.min-free-disk` option,
+// which is either a percentage or a size. Like DHT, a value without
+// unit which is not more than 100 is considered as percentage.
+func parseMinFreeDisk(value string) (percent float64, size float64, err error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if strings.HasSuffix(value, "%") {
+		percent, err = strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		return
+	}
+	numEnd := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	num, unit := value, ""
+	if numEnd >= 0 {
+		num, unit = value[:numEnd], strings.TrimSpace(value[numEnd:])
+	}
+	multiplier, ok := byteUnits[unit]
+	if !ok {
+		err = fmt.Errorf("unknown size unit %q", unit)
+		return
+	}
+	size, err = strconv.ParseFloat(num, 64)
+	if err != nil {
+		return
+	}
+	if unit == "" && size <= 100 {
+		return size, 0, nil
+	}
+	return 0, size * multiplier, nil
+}
+
+// subvolCapacity represents the effective capacity of a subvolume
+type subvolCapacity struct {
+	Total  float64
+	Used   float64
+	Usable float64
+}
+
+// getSubvolCapacity computes the effective capacity of a subvolume from
+// the status of its online data bricks, returns false if none of the
+// data bricks of the subvolume is online
+func getSubvolCapacity(subvol glusterutils.SubVolume, statuses map[string]glusterutils.BrickStatus,
+	minFreePercent float64, minFreeSize float64) (subvolCapacity, bool) {
+	var capacity subvolCapacity
+	var leastTotal, maxUsed float64
+	var available bool
+	for _, brick := range subvol.Bricks {
+		// Arbiter bricks store only metadata, they don't
+		// contribute to the subvolume capacity
+		if brick.Type == glusterconsts.BrickTypeArbiter {
+			continue
+		}
+		status, ok := statuses[brick.PeerID+":"+brick.Path]
+		if !ok || status.Status != 1 || status.Capacity == 0 {
+			continue
+		}
+		total := float64(status.Capacity)
+		used := float64(status.Capacity - status.Free)
+		if !available || total < leastTotal {
+			leastTotal = total
+		}
+		if used > maxUsed {
+			maxUsed = used
+		}
+		available = true
+	}
+	if !available {
+		return capacity, false
+	}
+
+	reserve := minFreeSize
+	if minFreePercent > 0 {
+		reserve = leastTotal * minFreePercent / 100
+	}
+	usable := math.Max(leastTotal-reserve, 0)
+
+	// In replicate and distribute subvolumes, each brick holds the
+	// complete data. In disperse subvolume, only the data bricks
+	// contribute to the sub volume size
+	multiplier := 1.0
+	if subvol.Type == glusterconsts.SubvolTypeDisperse {
+		dataCount := subvol.DisperseDataCount
+		if dataCount <= 0 {
+			dataCount = subvol.DisperseCount - subvol.DisperseRedundancyCount
+		}
+		multiplier = float64(dataCount)
+	}
+	capacity.Total = leastTotal * multiplier
+	capacity.Used = maxUsed * multiplier
+	capacity.Usable = usable * multiplier
+	return capacity, true
+}
+
+func volumeCapacity(gluster glusterutils.GInterface) error {
+	// Reset all vecs to not export stale information
+	for _, gaugeVec := range volumeCapacityGaugeVecs {
+		gaugeVec.RemoveStaleMetrics()
+	}
+
+	isLeader, err := gluster.IsLeader()
+	if err != nil {
+		log.WithError(err).Debug("Unable to find if the current node is leader")
+		return err
+	}
+	if !isLeader {
+		return nil
+	}
+
+	volumes, err := gluster.VolumeInfo()
+	if err != nil {
+		return err
+	}
+	volStatuses, err := gluster.VolumeStatus()
+	if err != nil {
+		return err
+	}
+	// status of all the bricks indexed by <peer id>:<brick path>
+	statuses := make(map[string]glusterutils.BrickStatus)
+	for _, volStatus := range volStatuses {
+		for _, node := range volStatus.Nodes {
+			statuses[node.PeerID+":"+node.Path] = node
+		}
+	}
+
+	for _, volume := range volumes {
+		if volume.State != glusterconsts.VolumeStateStarted {
+			continue
+		}
+		minFreeDisk := glusterconsts.DefaultMinFreeDisk
+		if value, exists := volume.Options[glusterconsts.MinFreeDiskGD1]; exists {
+			minFreeDisk = value
+		}
+		minFreePercent, minFreeSize, err := parseMinFreeDisk(minFreeDisk)
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"volume": volume.Name,
+				"value":  minFreeDisk,
+			}).Debug("Unable to parse min-free-disk option, ignoring it")
+			minFreePercent, minFreeSize = 0, 0
+		}
+
+		var volCapacity subvolCapacity
+		unavailable := 0
+		for _, subvol := range volume.SubVolumes {
+			capacity, ok := getSubvolCapacity(subvol, statuses, minFreePercent, minFreeSize)
+			if !ok {
+				log.WithFields(log.Fields{
+					"volume":    volume.Name,
+					"subvolume": subvol.Name,
+				}).Debug("No data brick of the subvolume is online")
+				unavailable++
+				continue
+			}
+			lbls := getGlusterSubvolLabels(volume.Name, subvol.Name)
+			volumeCapacityGaugeVecs[glusterVolumeCapacitySubvolTotal].Set(lbls, capacity.Total)
+			volumeCapacityGaugeVecs[glusterVolumeCapacitySubvolUsed].Set(lbls, capacity.Used)
+			volumeCapacityGaugeVecs[glusterVolumeCapacitySubvolUsable].Set(lbls, capacity.Usable)
+			volCapacity.Total += capacity.Total
+			volCapacity.Used += capacity.Used
+			volCapacity.Usable += capacity.Usable
+		}
+		lbls := getVolumeLabels(volume.Name)
+		volumeCapacityGaugeVecs[glusterVolumeCapacityTotal].Set(lbls, volCapacity.Total)
+		volumeCapacityGaugeVecs[glusterVolumeCapacityUsed].Set(lbls, volCapacity.Used)
+		volumeCapacityGaugeVecs[glusterVolumeCapacityUsable].Set(lbls, volCapacity.Usable)
+		volumeCapacityGaugeVecs[glusterVolumeCapacitySubvolsUnavailable].Set(lbls, float64(unavailable))
+	}
+	return nil
+}
+
+func init() {
+	registerOptionalMetric("gluster_volume_capacity", volumeCapacity, resetVecs(volumeCapacityGaugeVecs, nil))
+}
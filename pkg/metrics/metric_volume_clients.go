@@ -0,0 +1,250 @@
+package metrics
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils"
+	"github.com/gluster/gluster-prometheus/pkg/glusterutils/glusterconsts"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultClientIPLabelsLimit is the default value of ClientIPLabelsLimit
+const DefaultClientIPLabelsLimit = 100
+
+var (
+	// ClientIPLabels enables exporting the per client IP metrics
+	ClientIPLabels bool
+	// ClientIPLabelsLimit is the maximum no of client IPs per brick for which
+	// per client IP metrics are exported, bricks with more clients are skipped
+	ClientIPLabelsLimit = DefaultClientIPLabelsLimit
+
+	brickClientsLabels = []MetricLabel{
+		clusterIDLabel,
+		{
+			Name: "volume",
+			Help: "Volume Name",
+		},
+		{
+			Name: "hostname",
+			Help: "Host name or IP",
+		},
+		{
+			Name: "brick_path",
+			Help: "Brick Path",
+		},
+	}
+
+	brickClientsOpVersionLabels = []MetricLabel{
+		clusterIDLabel,
+		{
+			Name: "volume",
+			Help: "Volume Name",
+		},
+		{
+			Name: "hostname",
+			Help: "Host name or IP",
+		},
+		{
+			Name: "brick_path",
+			Help: "Brick Path",
+		},
+		{
+			Name: "op_version",
+			Help: "Op-version of the connected clients",
+		},
+	}
+
+	brickClientLabels = []MetricLabel{
+		clusterIDLabel,
+		{
+			Name: "volume",
+			Help: "Volume Name",
+		},
+		{
+			Name: "hostname",
+			Help: "Host name or IP",
+		},
+		{
+			Name: "brick_path",
+			Help: "Brick Path",
+		},
+		{
+			Name: "client_ip",
+			Help: "IP address of the client",
+		},
+	}
+
+	volumeClientsGaugeVecs = make(map[string]*ExportedGaugeVec)
+
+	glusterBrickClientsConnected = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_clients_connected",
+		Help:      "No of clients connected to the brick",
+		LongHelp:  "",
+		Labels:    brickClientsLabels,
+	}, &volumeClientsGaugeVecs)
+
+	glusterBrickClientsReadBytes = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_clients_read_bytes",
+		Help:      "Bytes read by all the clients connected to the brick",
+		LongHelp:  "",
+		Labels:    brickClientsLabels,
+	}, &volumeClientsGaugeVecs)
+
+	glusterBrickClientsWrittenBytes = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_clients_written_bytes",
+		Help:      "Bytes written by all the clients connected to the brick",
+		LongHelp:  "",
+		Labels:    brickClientsLabels,
+	}, &volumeClientsGaugeVecs)
+
+	glusterBrickClientsOpVersion = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_clients_op_version_count",
+		Help:      "No of clients connected to the brick with the given op-version",
+		LongHelp:  "",
+		Labels:    brickClientsOpVersionLabels,
+	}, &volumeClientsGaugeVecs)
+
+	glusterBrickClientReadBytes = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_client_read_bytes",
+		Help:      "Bytes read by a client from the brick",
+		LongHelp:  "Bytes read by a client from the brick. Only exported when `volume-clients-ip-labels` is enabled and the brick has no more than `volume-clients-ip-labels-limit` distinct client IPs.",
+		Labels:    brickClientLabels,
+	}, &volumeClientsGaugeVecs)
+
+	glusterBrickClientWrittenBytes = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_client_written_bytes",
+		Help:      "Bytes written by a client to the brick",
+		LongHelp:  "Bytes written by a client to the brick. Only exported when `volume-clients-ip-labels` is enabled and the brick has no more than `volume-clients-ip-labels-limit` distinct client IPs.",
+		Labels:    brickClientLabels,
+	}, &volumeClientsGaugeVecs)
+
+	glusterBrickClientOpVersion = registerExportedGaugeVec(Metric{
+		Namespace: "gluster",
+		Name:      "brick_client_op_version",
+		Help:      "Op-version of a client connected to the brick",
+		LongHelp:  "Op-version of a client connected to the brick. Only exported when `volume-clients-ip-labels` is enabled and the brick has no more than `volume-clients-ip-labels-limit` distinct client IPs.",
+		Labels:    brickClientLabels,
+	}, &volumeClientsGaugeVecs)
+)
+
+func getBrickClientsLabels(vol string, host string, brickPath string) prometheus.Labels {
+	return prometheus.Labels{
+		"cluster_id": ClusterID,
+		"volume":     vol,
+		"hostname":   host,
+		"brick_path": brickPath,
+	}
+}
+
+// clientIP strips the port from the client address
+func clientIP(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
+
+func volumeClients(gluster glusterutils.GInterface) error {
+	// Reset all vecs to not export stale information
+	for _, gaugeVec := range volumeClientsGaugeVecs {
+		gaugeVec.RemoveStaleMetrics()
+	}
+
+	isLeader, err := gluster.IsLeader()
+	if err != nil {
+		log.WithError(err).Debug("Unable to find if the current node is leader")
+		return err
+	}
+	if !isLeader {
+		return nil
+	}
+
+	volumes, err := gluster.VolumeInfo()
+	if err != nil {
+		return err
+	}
+
+	for _, volume := range volumes {
+		if volume.State != glusterconsts.VolumeStateStarted {
+			// Clients can connect only to started volumes
+			continue
+		}
+		bricks, err := gluster.VolumeClients(volume.Name)
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"volume": volume.Name,
+			}).Debug("Error getting volume clients")
+			continue
+		}
+		for _, brick := range bricks {
+			var bytesRead, bytesWritten uint64
+			opVersions := make(map[int]int)
+			// a client can have more than one connection to a brick,
+			// so aggregate the connections based on client IP
+			perIP := make(map[string]*glusterutils.BrickClient)
+			for _, client := range brick.Clients {
+				bytesRead += client.BytesRead
+				bytesWritten += client.BytesWritten
+				opVersions[client.OpVersion]++
+				ip := clientIP(client.Hostname)
+				if c, ok := perIP[ip]; ok {
+					c.BytesRead += client.BytesRead
+					c.BytesWritten += client.BytesWritten
+					if client.OpVersion > c.OpVersion {
+						c.OpVersion = client.OpVersion
+					}
+					continue
+				}
+				c := client
+				perIP[ip] = &c
+			}
+
+			lbls := getBrickClientsLabels(volume.Name, brick.Hostname, brick.Path)
+			volumeClientsGaugeVecs[glusterBrickClientsConnected].Set(lbls, float64(brick.ClientCount))
+			volumeClientsGaugeVecs[glusterBrickClientsReadBytes].Set(lbls, float64(bytesRead))
+			volumeClientsGaugeVecs[glusterBrickClientsWrittenBytes].Set(lbls, float64(bytesWritten))
+			for opVersion, count := range opVersions {
+				opLbls := getBrickClientsLabels(volume.Name, brick.Hostname, brick.Path)
+				opLbls["op_version"] = strconv.Itoa(opVersion)
+				volumeClientsGaugeVecs[glusterBrickClientsOpVersion].Set(opLbls, float64(count))
+			}
+
+			if !ClientIPLabels {
+				continue
+			}
+			// Cardinality guard, per client series for a brick with
+			// too many clients would flood the Prometheus server
+			if len(perIP) > ClientIPLabelsLimit {
+				log.WithFields(log.Fields{
+					"volume":     volume.Name,
+					"brick_path": brick.Path,
+					"clients":    len(perIP),
+					"limit":      ClientIPLabelsLimit,
+				}).Debug("Too many clients, skipping per client metrics")
+				continue
+			}
+			for ip, client := range perIP {
+				clientLbls := getBrickClientsLabels(volume.Name, brick.Hostname, brick.Path)
+				clientLbls["client_ip"] = ip
+				volumeClientsGaugeVecs[glusterBrickClientReadBytes].Set(clientLbls, float64(client.BytesRead))
+				volumeClientsGaugeVecs[glusterBrickClientWrittenBytes].Set(clientLbls, float64(client.BytesWritten))
+				volumeClientsGaugeVecs[glusterBrickClientOpVersion].Set(clientLbls, float64(client.OpVersion))
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerMetric("gluster_volume_clients", volumeClients, resetVecs(volumeClientsGaugeVecs, nil))
+}
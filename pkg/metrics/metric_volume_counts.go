@@ -158,5 +158,5 @@ func volumeCounts(gluster glusterutils.GInterface) error {
 }
 
 func init() {
-	registerMetric("gluster_volume_counts", volumeCounts)
+	registerMetric("gluster_volume_counts", volumeCounts, resetVecs(volumeCountGaugeVecs, nil))
 }
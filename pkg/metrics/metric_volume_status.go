@@ -158,5 +158,5 @@ func volumeInfo(gluster glusterutils.GInterface) (err error) {
 }
 
 func init() {
-	registerMetric("gluster_volume_status", volumeInfo)
+	registerMetric("gluster_volume_status", volumeInfo, resetVecs(volStatusGaugeVecs, nil))
 }
@@ -20,12 +20,37 @@ var (
 type GlusterMetric struct {
 	Name string
 	FN   func(glusterutils.GInterface) error
+	// Optional collectors run only when enabled in the configuration
+	Optional bool
+	// Reset removes all the metrics exported by the collector,
+	// called once the collector is disabled
+	Reset func()
 }
 
 var GlusterMetrics []GlusterMetric
 
-func registerMetric(name string, fn func(glusterutils.GInterface) error) {
-	GlusterMetrics = append(GlusterMetrics, GlusterMetric{Name: name, FN: fn})
+func registerMetric(name string, fn func(glusterutils.GInterface) error, reset func()) {
+	GlusterMetrics = append(GlusterMetrics, GlusterMetric{Name: name, FN: fn, Reset: reset})
+}
+
+// registerOptionalMetric registers a collector, which is disabled
+// unless it is configured with `disabled = false` in the collectors
+// configuration
+func registerOptionalMetric(name string, fn func(glusterutils.GInterface) error, reset func()) {
+	GlusterMetrics = append(GlusterMetrics, GlusterMetric{Name: name, FN: fn, Optional: true, Reset: reset})
+}
+
+// resetVecs returns the reset function of a collector
+// exporting the given gauge and counter vecs
+func resetVecs(gaugeVecs map[string]*ExportedGaugeVec, counterVecs map[string]*ExportedCounterVec) func() {
+	return func() {
+		for _, gaugeVec := range gaugeVecs {
+			gaugeVec.Reset()
+		}
+		for _, counterVec := range counterVecs {
+			counterVec.Reset()
+		}
+	}
 }
 
 // MetricLabel represents Prometheus Label
@@ -135,8 +160,129 @@ func (gv *ExportedGaugeVec) RemoveStaleMetrics() {
 	}
 }
 
+// Reset removes all the metrics of the GaugeVec
+func (gv *ExportedGaugeVec) Reset() {
+	gv.GaugeVec.Reset()
+	gv.Metrics = make(map[uint64]MetricWithTTL)
+}
+
 // Set updates the Gauge Value and last update time
 func (gv *ExportedGaugeVec) Set(labels prometheus.Labels, value float64) {
 	gv.GaugeVec.With(labels).Set(value)
 	gv.setMetricLastUpdated(labels)
 }
+
+// ExportedCounterVec represents each CounterVec with additional information
+type ExportedCounterVec struct {
+	Namespace  string
+	Name       string
+	Help       string
+	LongHelp   string
+	Labels     []string
+	CounterVec *prometheus.CounterVec
+	Metrics    map[uint64]MetricWithTTL
+	TTL        time.Duration
+	// last cumulative value set for each label combination
+	lastValues map[uint64]float64
+}
+
+func registerExportedCounterVec(m Metric, exported *map[string]*ExportedCounterVec) string {
+	counterVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: m.Namespace,
+			Name:      m.Name,
+			Help:      m.Help,
+		},
+		m.LabelNames(),
+	)
+
+	// Register the metric with Prometheus
+	prometheus.MustRegister(counterVec)
+	ttl := m.TTL
+	if ttl == 0 {
+		ttl = defaultMetricTTL
+	}
+
+	// Add the metric to the global queue
+	Metrics = append(Metrics, m)
+
+	(*exported)[m.Name] = &ExportedCounterVec{
+		Namespace:  m.Namespace,
+		Name:       m.Name,
+		Help:       m.Help,
+		LongHelp:   m.LongHelp,
+		Labels:     m.LabelNames(),
+		CounterVec: counterVec,
+		Metrics:    make(map[uint64]MetricWithTTL),
+		TTL:        ttl,
+		lastValues: make(map[uint64]float64),
+	}
+	return m.Name
+}
+
+func (cv *ExportedCounterVec) setMetricLastUpdated(labels prometheus.Labels) {
+	if cv.TTL > 0 {
+		// Get hash value of Metric labels
+		hash := model.LabelsToSignature(labels)
+		cv.Metrics[hash] = MetricWithTTL{
+			LastUpdated: time.Now(),
+			Labels:      labels,
+		}
+	}
+}
+
+// RemoveStaleMetrics removes all the stale metrics which are not
+// exported for TTL period.
+func (cv *ExportedCounterVec) RemoveStaleMetrics() {
+	if cv.TTL == 0 {
+		return
+	}
+
+	now := time.Now()
+	for hash, metric := range cv.Metrics {
+		if metric.LastUpdated.Add(cv.TTL).Before(now) {
+			cv.CounterVec.Delete(metric.Labels)
+			delete(cv.Metrics, hash)
+			delete(cv.lastValues, hash)
+		}
+	}
+}
+
+// Reset removes all the metrics of the CounterVec
+func (cv *ExportedCounterVec) Reset() {
+	cv.CounterVec.Reset()
+	cv.Metrics = make(map[uint64]MetricWithTTL)
+	cv.lastValues = make(map[uint64]float64)
+}
+
+// Add increments the Counter by the given value and updates the last update time
+func (cv *ExportedCounterVec) Add(labels prometheus.Labels, value float64) {
+	cv.CounterVec.With(labels).Add(value)
+	cv.setMetricLastUpdated(labels)
+}
+
+// Inc increments the Counter by one and updates the last update time
+func (cv *ExportedCounterVec) Inc(labels prometheus.Labels) {
+	cv.Add(labels, 1)
+}
+
+// Set updates the Counter to a cumulative value read from the system
+// (Ex: CPU time of a process). A value lower than the previously set
+// value is treated as a counter reset.
+func (cv *ExportedCounterVec) Set(labels prometheus.Labels, value float64) {
+	hash := model.LabelsToSignature(labels)
+	last, ok := cv.lastValues[hash]
+	if ok && value < last {
+		// counter reset, start the series afresh
+		cv.CounterVec.Delete(labels)
+		last = 0
+	}
+	if delta := value - last; delta > 0 {
+		cv.CounterVec.With(labels).Add(delta)
+	} else {
+		// make sure the series is exported even if unchanged
+		cv.CounterVec.With(labels)
+	}
+	cv.lastValues[hash] = value
+	cv.setMetricLastUpdated(labels)
+}
@@ -1,7 +1,7 @@
 #!/bin/bash
 
 REQ_GO_MAJOR_VERSION="1"
-REQ_GO_MINOR_VERSION="9"
+REQ_GO_MINOR_VERSION="18"
 
 REQ_GO_VERSION="$REQ_GO_MAJOR_VERSION.$REQ_GO_MINOR_VERSION"
 